@@ -0,0 +1,173 @@
+package nakedret
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const modulePath = "github.com/alexkohler/nakedret/v2"
+
+// Entries unused for cacheMaxAge are removed by trim, which runs at most every
+// cacheTrimInterval. Using an entry marks it as used, at most every
+// cacheUsedInterval to spare writes, like the Go build cache does.
+const (
+	cacheMaxAge       = 5 * 24 * time.Hour
+	cacheTrimInterval = 24 * time.Hour
+	cacheUsedInterval = time.Hour
+)
+
+// cacheTrimFile is the file of the cache directory whose modification time
+// is the last time it was trimmed.
+const cacheTrimFile = "trim.txt"
+
+// cache stores the findings of each file on disk. Entries are keyed by the
+// name and contents of the file, the configuration of the analyzer and the
+// version of nakedret, so that changing any of them invalidates them.
+type cache struct {
+	dir string
+	// salt is hashed into every key.
+	salt []byte
+}
+
+func newCache(dir string, runner NakedReturnRunner) (*cache, error) {
+	config, err := json.Marshal(runner)
+	if err != nil {
+		return nil, err
+	}
+	salt := []byte("nakedret cache v9\n" + toolVersion() + "\n")
+	return &cache{dir: dir, salt: append(salt, config...)}, nil
+}
+
+// toolVersion identifies the build of nakedret, from the information
+// embedded in the running binary.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	if info.Main.Path != modulePath {
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				if dep.Replace != nil {
+					dep = dep.Replace
+				}
+				version = dep.Version + " " + dep.Sum
+			}
+		}
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.modified":
+			version += " " + setting.Value
+		}
+	}
+	return info.GoVersion + " " + version
+}
+
+// key returns the key of the findings of filename, whose contents are src,
+// in a directory whose files affecting its findings, such as doc.go, have
+// the contents dirSrc.
+func (c *cache) key(filename string, src, dirSrc []byte) string {
+	h := sha256.New()
+	h.Write(c.salt)
+	h.Write([]byte("\n" + filename + "\x00"))
+	h.Write(src)
+	h.Write([]byte("\x00"))
+	h.Write(dirSrc)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the findings stored for key. Unreadable entries are treated as missing.
+func (c *cache) get(key string) ([]Finding, bool) {
+	path := c.path(key)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var findings []Finding
+	if err := json.Unmarshal(b, &findings); err != nil {
+		return nil, false
+	}
+	if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > cacheUsedInterval {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+	}
+	return findings, true
+}
+
+// trim removes the entries unused for cacheMaxAge, unless the cache was
+// trimmed less than cacheTrimInterval ago. It is best effort: entries that
+// cannot be removed are left for the next time.
+func (c *cache) trim() {
+	now := time.Now()
+	trimFile := filepath.Join(c.dir, cacheTrimFile)
+	if fi, err := os.Stat(trimFile); err == nil && now.Sub(fi.ModTime()) < cacheTrimInterval {
+		return
+	}
+	subdirs, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, subdir := range subdirs {
+		if !subdir.IsDir() || len(subdir.Name()) != 2 {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(c.dir, subdir.Name()))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".tmp") {
+				continue
+			}
+			if fi, err := entry.Info(); err == nil && now.Sub(fi.ModTime()) > cacheMaxAge {
+				os.Remove(filepath.Join(c.dir, subdir.Name(), name))
+			}
+		}
+	}
+	if os.WriteFile(trimFile, nil, 0o644) == nil {
+		os.Chtimes(trimFile, now, now)
+	}
+}
+
+func (c *cache) put(key string, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	b, err := json.Marshal(findings)
+	if err != nil {
+		return err
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that concurrent runs never read a partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
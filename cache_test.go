@@ -0,0 +1,148 @@
+package nakedret
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	filename := filepath.Join(dir, "x.go")
+	src := "package x\n\nfunc A() (err error) {\n\treturn\n}\n"
+	if err := os.WriteFile(filename, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(maxLength uint) []Finding {
+		t.Helper()
+		findings, err := Check([]string{filename}, Options{
+			NakedReturnRunner: NakedReturnRunner{MaxLength: maxLength},
+			CacheDir:          cacheDir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return findings
+	}
+
+	if findings := check(0); len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+
+	// Tamper with the stored entry to tell cached results apart from fresh ones.
+	c, err := newCache(cacheDir, NakedReturnRunner{MaxLength: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := c.key(filename, []byte(src), nil)
+	if _, ok := c.get(key); !ok {
+		t.Fatal("findings were not cached")
+	}
+	if err := c.put(key, []Finding{{Message: "cached"}}); err != nil {
+		t.Fatal(err)
+	}
+	if findings := check(0); len(findings) != 1 || findings[0].Message != "cached" {
+		t.Errorf("expected the cached finding, got %+v", findings)
+	}
+
+	// A different configuration does not use the entry.
+	if findings := check(5); len(findings) != 0 {
+		t.Errorf("expected no findings with a larger max length, got %+v", findings)
+	}
+	if findings := check(1); len(findings) != 1 || findings[0].Message == "cached" {
+		t.Errorf("expected a fresh finding, got %+v", findings)
+	}
+
+	// Neither does a changed file.
+	src = "package x\n\nfunc A() (err error) {\n\treturn err\n}\n"
+	if err := os.WriteFile(filename, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if findings := check(0); len(findings) != 0 {
+		t.Errorf("expected no findings after the file changed, got %+v", findings)
+	}
+}
+
+func TestCacheShadowingResults(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	write := func(filename, src string) {
+		t.Helper()
+		if err := os.WriteFile(filename, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(a, "package x\n\nfunc F() (count int) {\n\treturn 1\n}\n")
+	write(b, "package x\n")
+
+	check := func() []Finding {
+		t.Helper()
+		findings, err := Check([]string{dir + "/..."}, Options{
+			NakedReturnRunner: NakedReturnRunner{MaxLength: 5, ReportShadowingResults: true},
+			CacheDir:          cacheDir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return findings
+	}
+
+	if findings := check(); len(findings) != 0 {
+		t.Fatalf("expected no findings, got %+v", findings)
+	}
+
+	// The findings of a.go depend on the declarations of b.go.
+	write(b, "package x\n\nvar count = 1\n")
+	if findings := check(); len(findings) != 1 || findings[0].Rule != RuleShadowingResult || findings[0].Pos.Filename != a {
+		t.Errorf("expected a shadowing result in a.go after b.go changed, got %+v", findings)
+	}
+
+	write(b, "package x\n")
+	if findings := check(); len(findings) != 0 {
+		t.Errorf("expected no findings after b.go was restored, got %+v", findings)
+	}
+}
+
+func TestCacheTrim(t *testing.T) {
+	c, err := newCache(t.TempDir(), NakedReturnRunner{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, stale, used := c.key("fresh.go", nil, nil), c.key("stale.go", nil, nil), c.key("used.go", nil, nil)
+	for _, key := range []string{fresh, stale, used} {
+		if err := c.put(key, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-cacheMaxAge - time.Hour)
+	for _, key := range []string{stale, used} {
+		if err := os.Chtimes(c.path(key), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Using an entry keeps it.
+	if _, ok := c.get(used); !ok {
+		t.Fatal("entry not found")
+	}
+
+	c.trim()
+	for key, kept := range map[string]bool{fresh: true, stale: false, used: true} {
+		if _, err := os.Stat(c.path(key)); (err == nil) != kept {
+			t.Errorf("entry %s: got stat error %v, expected kept %v", key, err, kept)
+		}
+	}
+
+	// The cache is trimmed at most once per interval.
+	if err := os.Chtimes(c.path(fresh), old, old); err != nil {
+		t.Fatal(err)
+	}
+	c.trim()
+	if _, err := os.Stat(c.path(fresh)); err != nil {
+		t.Errorf("entry trimmed again within the interval: %v", err)
+	}
+}
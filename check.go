@@ -0,0 +1,317 @@
+package nakedret
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+// Options configures Check.
+type Options struct {
+	NakedReturnRunner
+
+	// Overlay maps file names to contents that are used instead of the
+	// files on disk, e.g. to check unsaved editor buffers.
+	Overlay map[string][]byte
+
+	// Jobs is the maximum number of files parsed or packages analyzed
+	// concurrently. It defaults to the number of CPUs.
+	Jobs int
+
+	// CacheDir is the directory in which the findings of each file are
+	// stored, so that files which did not change since a previous run with
+	// the same configuration are not analyzed again. Caching is disabled if
+	// it is empty.
+	CacheDir string
+}
+
+// Finding is a naked return reported by Check.
+type Finding struct {
+	// Pos and End delimit the offending return statement.
+	Pos Position `json:"pos"`
+	End Position `json:"end"`
+	// Message is the human readable description of the finding.
+	Message string `json:"message"`
+	// Rule is the ID of the rule reporting the finding, see Rules.
+	Rule string `json:"rule"`
+	// Edits is the suggested fix, if any, e.g. the explicit return statement
+	// replacing a naked return.
+	Edits []TextEdit `json:"edits,omitempty"`
+
+	// Func is the name of the function containing the naked return,
+	// prefixed by the names of the functions enclosing it.
+	Func string `json:"func,omitempty"`
+	// FuncPos is the start of the function.
+	FuncPos Position `json:"funcPos"`
+	// Length is the number of lines of the function and MaxLength the
+	// limit it exceeds.
+	Length    int  `json:"length,omitempty"`
+	MaxLength uint `json:"maxLength,omitempty"`
+	// Depth is the nesting depth of the naked return when deeper than
+	// NakedReturnRunner.MaxDepth, the deepest of the related ones if grouped.
+	Depth int `json:"depth,omitempty"`
+	// Severity classifies the finding, see Severity.
+	Severity Severity `json:"severity"`
+
+	// Related holds the naked returns of the function, each with its own
+	// fix, when findings are grouped by function, or the declaration of the
+	// identifier shadowed by a named result.
+	Related []Finding `json:"related,omitempty"`
+}
+
+// TextEdit replaces the source between Pos and End with NewText.
+type TextEdit struct {
+	Pos     Position `json:"pos"`
+	End     Position `json:"end"`
+	NewText string   `json:"newText"`
+}
+
+// Position is a position in a source file. It has the fields of
+// token.Position, which it can be converted to and from.
+type Position struct {
+	Filename string `json:"filename"`
+	// Offset is the byte offset of the position, starting at 0.
+	Offset int `json:"offset"`
+	// Line and Column start at 1, Column counting bytes.
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// String returns the position in the forms of token.Position.String, e.g.
+// file.go:3:2.
+func (p Position) String() string {
+	return token.Position(p).String()
+}
+
+// position returns the position of p in fset.
+func position(fset *token.FileSet, p token.Pos) Position {
+	return Position(fset.Position(p))
+}
+
+// Check parses the files, directories or packages named by patterns and
+// returns the naked returns found in them, sorted by position. Packages are
+// analyzed concurrently, see Options.Jobs.
+func Check(patterns []string, opts Options) ([]Finding, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	filenames, _, err := opts.inputFiles(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse input: %v", err)
+	}
+	srcs, err := opts.readFiles(filenames)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse input: %v", err)
+	}
+
+	var c *cache
+	if opts.CacheDir != "" {
+		if c, err = newCache(opts.CacheDir, opts.NakedReturnRunner); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only parse and analyze the files without cached findings. The
+	// directives of doc.go apply to the whole package: it is part of the key
+	// of the files of its directory, which are all analyzed again if one of
+	// them is. So is every file of the directory when reporting
+	// RuleShadowingResult, as named results can shadow the package-level
+	// declarations of the other files.
+	dirSrcs := make(map[string][]byte)
+	for i, filename := range filenames {
+		dir := filepath.Dir(filename)
+		if opts.ReportShadowingResults {
+			dirSrcs[dir] = append(dirSrcs[dir], filename+"\x00"...)
+			dirSrcs[dir] = append(append(dirSrcs[dir], srcs[i]...), 0)
+		} else if filepath.Base(filename) == docFile {
+			dirSrcs[dir] = srcs[i]
+		}
+	}
+	keys := make(map[string]string)
+	cached := make(map[string][]Finding)
+	missedDirs := make(map[string]bool)
+	if c != nil {
+		for i, filename := range filenames {
+			dir := filepath.Dir(filename)
+			keys[filename] = c.key(filename, srcs[i], dirSrcs[dir])
+			if findings, ok := c.get(keys[filename]); ok {
+				cached[filename] = findings
+			} else if dirSrcs[dir] != nil {
+				missedDirs[dir] = true
+			}
+		}
+	}
+	var findings []Finding
+	var missedNames []string
+	var missedSrcs [][]byte
+	for i, filename := range filenames {
+		if f, ok := cached[filename]; ok && !missedDirs[filepath.Dir(filename)] {
+			findings = append(findings, f...)
+			continue
+		}
+		missedNames = append(missedNames, filename)
+		missedSrcs = append(missedSrcs, srcs[i])
+	}
+
+	fset := token.NewFileSet()
+	files, err := opts.parseFiles(fset, missedNames, missedSrcs)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse input: %v", err)
+	}
+
+	pkgFindings, err := opts.checkPackages(fset, groupPackages(fset, files))
+	if err != nil {
+		return nil, err
+	}
+
+	byFile := make(map[string][]Finding)
+	for _, pf := range pkgFindings {
+		for _, f := range pf {
+			byFile[f.Pos.Filename] = append(byFile[f.Pos.Filename], f)
+		}
+		findings = append(findings, pf...)
+	}
+	if c != nil {
+		for _, filename := range missedNames {
+			if err := c.put(keys[filename], byFile[filename]); err != nil {
+				return nil, err
+			}
+		}
+		c.trim()
+	}
+	sortFindings(findings)
+
+	return findings, nil
+}
+
+// sortFindings sorts findings by file name and offset.
+func sortFindings(findings []Finding) {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(a.Pos.Filename, b.Pos.Filename),
+			cmp.Compare(a.Pos.Offset, b.Pos.Offset),
+		)
+	})
+}
+
+// pkg holds the files of a package, which are analyzed in a single pass.
+type pkg struct {
+	dir   string
+	name  string
+	files []*ast.File
+}
+
+// groupPackages splits files by directory and package name, keeping the
+// order in which packages and files first appear.
+func groupPackages(fset *token.FileSet, files []*ast.File) []*pkg {
+	type key struct{ dir, name string }
+	var pkgs []*pkg
+	byKey := make(map[key]*pkg)
+	for _, f := range files {
+		k := key{filepath.Dir(fset.File(f.Pos()).Name()), f.Name.Name}
+		p, ok := byKey[k]
+		if !ok {
+			p = &pkg{dir: k.dir, name: k.name}
+			byKey[k] = p
+			pkgs = append(pkgs, p)
+		}
+		p.files = append(p.files, f)
+	}
+	return pkgs
+}
+
+// checkPackages analyzes pkgs concurrently and returns the findings of each of them.
+func (opts Options) checkPackages(fset *token.FileSet, pkgs []*pkg) ([][]Finding, error) {
+	findings := make([][]Finding, len(pkgs))
+	err := forEach(len(pkgs), opts.Jobs, func(i int) (err error) {
+		findings[i], err = opts.check(fset, pkgs[i].files)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+// check runs the analyzer over files in a single pass.
+func (opts Options) check(fset *token.FileSet, files []*ast.File) ([]Finding, error) {
+	pass, err := opts.newPass(fset, files)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	opts.visit(pass, func(r nakedReturn) {
+		findings = append(findings, newFinding(fset, r))
+	}, nil)
+
+	return findings, nil
+}
+
+// newPass returns a pass over files with the results of the analyzers
+// required by NakedReturnAnalyzer.
+func (opts Options) newPass(fset *token.FileSet, files []*ast.File) (*analysis.Pass, error) {
+	runner := opts.NakedReturnRunner
+	pass := &analysis.Pass{
+		Analyzer: NakedReturnAnalyzer(&runner),
+		Fset:     fset,
+		Files:    files,
+		Report:   func(analysis.Diagnostic) {},
+		ResultOf: map[*analysis.Analyzer]any{},
+	}
+	result, err := inspect.Analyzer.Run(pass)
+	if err != nil {
+		return nil, err
+	}
+	pass.ResultOf[inspect.Analyzer] = result
+	return pass, nil
+}
+
+func newFinding(fset *token.FileSet, r nakedReturn) Finding {
+	d := r.diagnostic
+	f := Finding{
+		Pos:       position(fset, d.Pos),
+		End:       position(fset, d.End),
+		Message:   d.Message,
+		Rule:      d.Category,
+		Func:      r.funcName,
+		FuncPos:   position(fset, r.funcPos),
+		Length:    r.funcLength,
+		MaxLength: r.maxLength,
+		Depth:     r.depth,
+		Severity:  r.severity,
+	}
+	for _, fix := range d.SuggestedFixes {
+		for _, edit := range fix.TextEdits {
+			f.Edits = append(f.Edits, TextEdit{
+				Pos:     position(fset, edit.Pos),
+				End:     position(fset, edit.End),
+				NewText: string(edit.NewText),
+			})
+		}
+	}
+	// The fix of findings grouped by function has an edit per naked return
+	// that can be made explicit.
+	for _, rel := range d.Related {
+		related := Finding{
+			Pos:      position(fset, rel.Pos),
+			End:      position(fset, rel.End),
+			Message:  rel.Message,
+			Rule:     d.Category,
+			Severity: r.severity,
+		}
+		for i, edit := range f.Edits {
+			if edit.Pos == related.Pos && edit.End == related.End {
+				related.Edits = f.Edits[i : i+1 : i+1]
+			}
+		}
+		f.Related = append(f.Related, related)
+	}
+	return f
+}
@@ -0,0 +1,161 @@
+package nakedret
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	findings, err := Check([]string{"testdata/src/x/example.go"}, Options{NakedReturnRunner: NakedReturnRunner{MaxLength: 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct{ message, fix string }{
+		{"naked return in func `three` with 5 lines of code", "return one, two, three"},
+		{"naked return in func `longFunc` with 34 lines of code", "return story"},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("got %d findings, expected %d: %+v", len(findings), len(expected), findings)
+	}
+	for i, f := range findings {
+		if f.Pos.Filename != "testdata/src/x/example.go" {
+			t.Errorf("finding %d: unexpected filename %q", i, f.Pos.Filename)
+		}
+		if f.Message != expected[i].message {
+			t.Errorf("finding %d: got message %q, expected %q", i, f.Message, expected[i].message)
+		}
+		// The fix replaces the naked return.
+		if len(f.Edits) != 1 || f.Edits[0].NewText != expected[i].fix || f.Edits[0].Pos != f.Pos || f.Edits[0].End != f.End {
+			t.Errorf("finding %d: got edits %+v, expected %q", i, f.Edits, expected[i].fix)
+		}
+	}
+	if findings[0].Pos.Line != 18 || findings[1].Pos.Line != 54 {
+		t.Errorf("unexpected lines %d and %d", findings[0].Pos.Line, findings[1].Pos.Line)
+	}
+	if f := findings[1]; f.Func != "longFunc" || f.FuncPos.Line != 21 || f.Length != 34 || f.MaxLength != 4 {
+		t.Errorf("unexpected function details %+v", f)
+	}
+}
+
+func TestCheckInvalidInput(t *testing.T) {
+	if _, err := Check([]string{"testdata/src/x/example.go.golden"}, Options{}); err == nil {
+		t.Fatal("expected error for non-Go file")
+	}
+}
+
+func TestCheckDirectorySorted(t *testing.T) {
+	findings, err := Check([]string{"testdata/src/x"}, Options{Jobs: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 27 {
+		t.Errorf("got %d findings, expected 27", len(findings))
+	}
+	for i := 1; i < len(findings); i++ {
+		prev, cur := findings[i-1].Pos, findings[i].Pos
+		if prev.Filename > cur.Filename || prev.Filename == cur.Filename && prev.Offset > cur.Offset {
+			t.Errorf("findings not sorted: %v before %v", prev, cur)
+		}
+	}
+}
+
+// writeTree generates a tree of packages with functions using naked returns.
+func writeTree(tb testing.TB, dir string, packages, files, funcs int) {
+	tb.Helper()
+	for p := 0; p < packages; p++ {
+		pkgDir := filepath.Join(dir, fmt.Sprintf("p%d", p))
+		if err := os.Mkdir(pkgDir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := 0; f < files; f++ {
+			var b strings.Builder
+			fmt.Fprintf(&b, "package p%d\n", p)
+			for fn := 0; fn < funcs; fn++ {
+				fmt.Fprintf(&b, "\nfunc F%d_%d(x int) (y int, err error) {\n", f, fn)
+				for l := 0; l < fn; l++ {
+					fmt.Fprintf(&b, "\ty += x * %d\n", l)
+				}
+				b.WriteString("\tif y > 0 {\n\t\treturn\n\t}\n\treturn y, nil\n}\n")
+			}
+			if err := os.WriteFile(filepath.Join(pkgDir, fmt.Sprintf("f%d.go", f)), []byte(b.String()), 0o644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCheck(b *testing.B) {
+	dir := b.TempDir()
+	writeTree(b, dir, 100, 20, 10)
+
+	for _, jobs := range []int{1, 0} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Check([]string{dir + "/..."}, Options{Jobs: jobs}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckOverlay(t *testing.T) {
+	dir := t.TempDir()
+	onDisk := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(onDisk, []byte("package x\n\nfunc A() (err error) {\n\treturn\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The unsaved buffer of a.go, named with a relative path, and a new file.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err := filepath.Rel(wd, onDisk)
+	if err != nil {
+		t.Skip("temporary directory not reachable with a relative path")
+	}
+	unsaved := filepath.Join(dir, "b.go")
+	overlay := map[string][]byte{
+		edited:  []byte("package x\n\nfunc A() (err error) {\n\treturn err\n}\n\nfunc A2() (err error) {\n\treturn\n}\n"),
+		unsaved: []byte("package x\n\nfunc B() (err error) {\n\treturn\n}\n"),
+	}
+
+	for _, patterns := range [][]string{{dir}, {onDisk, unsaved}} {
+		findings, err := Check(patterns, Options{Overlay: overlay})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(findings) != 2 {
+			t.Fatalf("%v: expected 2 findings, got %+v", patterns, findings)
+		}
+		if f := findings[0]; f.Pos.Filename != edited || f.Pos.Line != 8 || len(f.Edits) != 1 || f.Edits[0].NewText != "return err" {
+			t.Errorf("%v: unexpected finding %+v", patterns, f)
+		}
+		if f := findings[1]; f.Pos.Filename != unsaved || f.Pos.Line != 4 {
+			t.Errorf("%v: unexpected finding %+v", patterns, f)
+		}
+	}
+}
+
+func TestCheckGroupByFunction(t *testing.T) {
+	findings, err := Check([]string{"testdata/src/grouped/grouped.go"}, Options{NakedReturnRunner: NakedReturnRunner{GroupByFunction: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+	f := findings[0]
+	if f.Pos.Line != 3 || f.Func != "ManyReturns" || len(f.Edits) != 3 || len(f.Related) != 3 {
+		t.Fatalf("unexpected finding %+v", f)
+	}
+	for i, line := range []int{6, 8, 10} {
+		if rel := f.Related[i]; rel.Pos.Line != line || len(rel.Edits) != 1 || rel.Edits[0].NewText != "return x, y, err" || rel.Edits[0] != f.Edits[i] {
+			t.Errorf("unexpected related finding %+v", rel)
+		}
+	}
+}
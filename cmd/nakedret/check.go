@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+// Output formats of the standalone driver, in addition to the ones of
+// nakedret.NewReporter.
+const (
+	formatText   = "text"
+	formatPretty = "pretty"
+)
+
+// output configures what runCheck prints.
+type output struct {
+	format string
+	// stats prints the statistics of each package after the findings, with
+	// the statsTop longest functions.
+	stats    bool
+	statsTop int
+	// failOn is the lowest severity of the findings failing the run.
+	failOn nakedret.Severity
+}
+
+// runCheck prints the findings in args and returns the exit status, which is
+// 3 like with singlechecker when something at least as severe as out.failOn
+// was found.
+func runCheck(opts nakedret.Options, args []string, out output) int {
+	log.SetFlags(0)
+	log.SetPrefix("nakedret: ")
+
+	r, err := newReporter(out.format, opts.Overlay)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	findings, err := nakedret.Run(args, opts, r)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if out.stats {
+		stats, err := nakedret.Stats(args, opts)
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		printStats(os.Stdout, stats, out.statsTop)
+	}
+	return exitStatus(findings, out.failOn)
+}
+
+func exitStatus(findings []nakedret.Finding, failOn nakedret.Severity) int {
+	for _, f := range findings {
+		if f.Severity >= failOn {
+			return 3
+		}
+	}
+	return 0
+}
+
+// newReporter returns the reporter writing findings to stdout in the given format.
+func newReporter(format string, overlay map[string][]byte) (nakedret.Reporter, error) {
+	if format == formatPretty {
+		return &prettyPrinter{
+			w:       os.Stdout,
+			color:   isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "",
+			overlay: overlay,
+		}, nil
+	}
+	return nakedret.NewReporter(format, os.Stdout)
+}
+
+// cacheDirectory returns the directory of the result cache, or the empty
+// string if caching is disabled or no directory is available.
+func cacheDirectory(dir string, noCache bool) string {
+	if noCache {
+		return ""
+	}
+	if dir != "" {
+		return dir
+	}
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(userCacheDir, "nakedret")
+}
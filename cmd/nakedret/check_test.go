@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+func TestExitStatus(t *testing.T) {
+	findings := []nakedret.Finding{{Severity: nakedret.SeverityInfo}, {Severity: nakedret.SeverityWarning}}
+	for _, tt := range []struct {
+		failOn   nakedret.Severity
+		expected int
+	}{
+		{nakedret.SeverityInfo, 3},
+		{nakedret.SeverityWarning, 3},
+		{nakedret.SeverityError, 0},
+	} {
+		if got := exitStatus(findings, tt.failOn); got != tt.expected {
+			t.Errorf("-fail-on=%s: got exit status %d, expected %d", tt.failOn, got, tt.expected)
+		}
+	}
+	if got := exitStatus(nil, nakedret.SeverityInfo); got != 0 {
+		t.Errorf("got exit status %d without findings", got)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+// runListDirectives prints the directives in args and returns the exit
+// status, which is 3 when some of them are invalid.
+func runListDirectives(opts nakedret.Options, args []string) int {
+	log.SetFlags(0)
+	log.SetPrefix("nakedret: ")
+
+	directives, err := nakedret.Directives(args, opts)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if printDirectives(os.Stdout, directives) {
+		return 3
+	}
+	return 0
+}
+
+// printDirectives writes one directive per line and reports whether some of
+// them are invalid.
+func printDirectives(w io.Writer, directives []nakedret.Directive) (invalid bool) {
+	for _, d := range directives {
+		text := strings.TrimSpace("//nakedret:" + d.Name + " " + d.Args)
+		if d.Err != "" {
+			fmt.Fprintf(w, "%s: %s: invalid: %s\n", d.Pos, text, d.Err)
+			invalid = true
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s (%s)\n", d.Pos, text, d.Scope)
+	}
+	return invalid
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"go/token"
+	"testing"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+func TestPrintDirectives(t *testing.T) {
+	pos := token.Position{Filename: "x.go", Line: 3, Column: 1}
+	var b bytes.Buffer
+	invalid := printDirectives(&b, []nakedret.Directive{
+		{Pos: pos, Name: "max-length", Args: "20", Scope: "func F"},
+		{Pos: pos, Name: "ignore", Scope: "line"},
+		{Pos: pos, Name: "max-length", Args: "x", Err: `invalid number of lines "x"`},
+	})
+	expected := "x.go:3:1: //nakedret:max-length 20 (func F)\n" +
+		"x.go:3:1: //nakedret:ignore (line)\n" +
+		"x.go:3:1: //nakedret:max-length x: invalid: invalid number of lines \"x\"\n"
+	if b.String() != expected || !invalid {
+		t.Errorf("got %q (invalid %v), expected %q", b.String(), invalid, expected)
+	}
+}
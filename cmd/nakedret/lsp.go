@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/alexkohler/nakedret/v2"
+	"github.com/alexkohler/nakedret/v2/lsp"
+)
+
+// runLSP serves the language server protocol over stdin and stdout until the client exits.
+func runLSP(nakedRet *nakedret.NakedReturnRunner, flags *flag.FlagSet, args []string) {
+	flags.Parse(args)
+
+	log.SetFlags(0)
+	log.SetPrefix("nakedret: ")
+
+	server := lsp.NewServer(nakedret.Options{NakedReturnRunner: *nakedRet})
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
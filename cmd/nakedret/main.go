@@ -4,40 +4,172 @@ import (
 	"flag"
 	"fmt"
 	"go/build"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strings"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/singlechecker"
 
 	"github.com/alexkohler/nakedret/v2"
 )
 
-const (
-	DefaultLines         = 5
-	DefaultSkipTestFiles = false
-)
-
 func init() {
 	// TODO allow build tags
 	build.Default.UseAllFiles = true
 }
 
 func main() {
-	nakedRet := &nakedret.NakedReturnRunner{}
+	nakedRet := nakedret.DefaultRunner
+	analyzer := nakedret.Analyzer
+	analyzer.Flags.Var(versionFlag{}, "V", "print version and exit")
 
-	analyzer := nakedret.NakedReturnAnalyzer(nakedRet)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lsp":
+			runLSP(nakedRet, &analyzer.Flags, os.Args[2:])
+			return
+		case "suggest":
+			runSuggest(nakedRet, &analyzer.Flags, os.Args[2:])
+			return
+		}
+	}
 
-	analyzer.Flags.Init("nakedret", flag.ExitOnError)
+	// Flags of the standalone driver. Setting any of them runs nakedret
+	// without going through singlechecker, see parseStandalone.
+	var (
+		watch    bool
+		jobs     int
+		cacheDir string
+		noCache  bool
 
-	analyzer.Flags.UintVar(&nakedRet.MaxLength, "l", DefaultLines, "maximum number of lines for a naked return function")
-	analyzer.Flags.BoolVar(&nakedRet.SkipTestFiles, "skip-test-files", DefaultSkipTestFiles, "set to true to skip test files")
-	analyzer.Flags.Var(versionFlag{}, "V", "print version and exit")
+		stdin         bool
+		stdinFilename string
+
+		format   string
+		stats    bool
+		statsTop int
+		failOn   = nakedret.SeverityWarning
+
+		listDirectives bool
+	)
+	flag.BoolVar(&watch, "watch", false, "keep running and report new and resolved findings whenever a Go file changes")
+	flag.IntVar(&jobs, "j", 0, "maximum number of files parsed or packages analyzed in parallel (default: number of CPUs)")
+	flag.StringVar(&cacheDir, "cache-dir", "", "directory storing the findings of unchanged files between runs (default: nakedret in the user cache directory)")
+	flag.BoolVar(&noCache, "no-cache", false, "do not read or write cached findings")
+	flag.StringVar(&format, "format", formatText, "output format: text, json, or pretty to group findings by file and function with their source")
+	flag.BoolVar(&stats, "stats", false, "print statistics about the functions of each package after the findings")
+	flag.IntVar(&statsTop, "stats-top", 10, "number of longest functions with naked returns listed by -stats")
+	flag.Var(&failOn, "fail-on", "lowest severity of the findings making nakedret exit with status 3: error, warning or info")
+	flag.BoolVar(&listDirectives, "list-directives", false, "list the //nakedret: directives and their scope instead of checking, exiting with status 3 if some are invalid")
+	flag.BoolVar(&stdin, "stdin", false, "read the contents of the file named by -stdin-filename from standard input")
+	flag.StringVar(&stdinFilename, "stdin-filename", "", "name of the file read with -stdin, checked alone unless its package is named too")
+
+	args, ok, err := parseStandalone(analyzer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nakedret: %v\n", err)
+		os.Exit(2)
+	}
+	if ok {
+		opts := nakedret.Options{
+			NakedReturnRunner: *nakedRet,
+			Jobs:              jobs,
+			CacheDir:          cacheDirectory(cacheDir, noCache),
+		}
+		if statsTop < 0 {
+			fmt.Fprintln(os.Stderr, "nakedret: -stats-top must not be negative")
+			os.Exit(2)
+		}
+		if stdin {
+			if stdinFilename == "" {
+				fmt.Fprintln(os.Stderr, "nakedret: -stdin requires -stdin-filename")
+				os.Exit(2)
+			}
+			src, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "nakedret: reading standard input: %v\n", err)
+				os.Exit(1)
+			}
+			opts.Overlay = map[string][]byte{stdinFilename: src}
+			if len(args) == 0 {
+				args = []string{stdinFilename}
+			}
+		}
+		if listDirectives {
+			os.Exit(runListDirectives(opts, args))
+		}
+		if watch {
+			runWatch(opts, args)
+			return
+		}
+		os.Exit(runCheck(opts, args, output{format: format, stats: stats, statsTop: statsTop, failOn: failOn}))
+	}
 
 	singlechecker.Main(analyzer)
 }
 
+// parseStandalone parses the command line with the analyzer flags and the
+// flags registered on flag.CommandLine. It reports false if the command line
+// does not set any of the flags registered on flag.CommandLine, so that
+// singlechecker loads the packages with their type information. It returns an
+// error if the command line sets some of them while being meant for
+// singlechecker: it uses flags only singlechecker knows, such as -fix, or
+// names a single .cfg file as go vet does.
+func parseStandalone(analyzer *analysis.Analyzer) ([]string, bool, error) {
+	fs := flag.NewFlagSet(analyzer.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if name := standaloneFlag(os.Args[1:]); name != "" && err != flag.ErrHelp {
+			return nil, false, fmt.Errorf("-%s cannot be combined with the flags of the analysis driver: %v", name, err)
+		}
+		return nil, false, nil
+	}
+	if args := fs.Args(); len(args) == 1 && strings.HasSuffix(args[0], ".cfg") {
+		if name := standaloneFlag(os.Args[1:]); name != "" {
+			return nil, false, fmt.Errorf("-%s cannot be used with go vet", name)
+		}
+		return nil, false, nil
+	}
+	standalone := false
+	fs.Visit(func(f *flag.Flag) {
+		if flag.Lookup(f.Name) != nil {
+			standalone = true
+		}
+	})
+	if !standalone {
+		return nil, false, nil
+	}
+	return fs.Args(), true, nil
+}
+
+// standaloneFlag returns the name of the first flag of flag.CommandLine used
+// in args, if any. Flag values and arguments are skipped as they cannot be
+// told apart without parsing.
+func standaloneFlag(args []string) string {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if flag.Lookup(name) != nil {
+			return name
+		}
+	}
+	return ""
+}
+
 type versionFlag struct{}
 
 func (versionFlag) IsBoolFlag() bool { return true }
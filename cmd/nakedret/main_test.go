@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+func TestParseStandalone(t *testing.T) {
+	saved := os.Args
+	defer func() { os.Args = saved }()
+
+	analyzer := nakedret.NakedReturnAnalyzer(&nakedret.NakedReturnRunner{})
+	analyzer.Flags.Init(analyzer.Name, flag.ContinueOnError)
+	(&nakedret.NakedReturnRunner{}).RegisterFlags(&analyzer.Flags)
+	if flag.Lookup("format") == nil {
+		flag.String("format", formatText, "")
+	}
+
+	for _, tt := range []struct {
+		args       []string
+		standalone bool
+		wantErr    bool
+		rest       []string
+	}{
+		{[]string{"./..."}, false, false, nil},
+		{[]string{"-report-short", "./..."}, false, false, nil},
+		{[]string{"-format", "json", "."}, true, false, []string{"."}},
+		{[]string{"-report-short", "-format=json", "./..."}, true, false, []string{"./..."}},
+		{[]string{"-l", "0", "-fix", "."}, false, false, nil},
+		{[]string{"vet.cfg"}, false, false, nil},
+		{[]string{"-l", "0", "-format", "json", "-fix", "."}, false, true, nil},
+		{[]string{"-format=json", "vet.cfg"}, false, true, nil},
+	} {
+		os.Args = append([]string{"nakedret"}, tt.args...)
+		rest, standalone, err := parseStandalone(analyzer)
+		if (err != nil) != tt.wantErr || standalone != tt.standalone || !slices.Equal(rest, tt.rest) {
+			t.Errorf("%q: got %q, %v, %v", tt.args, rest, standalone, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+// ANSI escape sequences used by the pretty format.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+)
+
+// nakedReturnRules are the rules reporting naked returns, which are listed
+// under their function.
+var nakedReturnRules = []string{
+	nakedret.RuleLongFunc,
+	nakedret.RuleShadowedResult,
+	nakedret.RuleShortExported,
+	nakedret.RuleDeepReturn,
+}
+
+// prettyPrinter writes findings grouped by file and function, along with the
+// source lines of the naked returns.
+type prettyPrinter struct {
+	w     io.Writer
+	color bool
+	// overlay holds the contents of files that are not read from disk.
+	overlay map[string][]byte
+
+	// findings are the findings reported so far, printed by Finish.
+	findings []nakedret.Finding
+}
+
+// isTerminal reports whether f is a character device, as terminals are.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *prettyPrinter) style(code, s string) string {
+	if !p.color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (p *prettyPrinter) Start() error { return nil }
+
+func (p *prettyPrinter) Report(f nakedret.Finding) error {
+	p.findings = append(p.findings, f)
+	return nil
+}
+
+func (p *prettyPrinter) Finish() error {
+	return p.print(p.findings)
+}
+
+// print writes findings, which must be sorted by position.
+func (p *prettyPrinter) print(findings []nakedret.Finding) error {
+	returns, funcs := 0, 0
+	for i := 0; i < len(findings); {
+		j := i + 1
+		for j < len(findings) && findings[j].Pos.Filename == findings[i].Pos.Filename {
+			j++
+		}
+		r, f, err := p.printFile(findings[i:j])
+		if err != nil {
+			return err
+		}
+		returns += r
+		funcs += f
+		i = j
+	}
+	if funcs > 0 {
+		fmt.Fprintf(p.w, "%s in %s\n", plural(returns, "naked return"), plural(funcs, "function"))
+	}
+	return nil
+}
+
+// printFile writes the findings of a single file and returns the number of
+// naked returns and of functions they belong to.
+func (p *prettyPrinter) printFile(findings []nakedret.Finding) (returns, funcs int, err error) {
+	filename := findings[0].Pos.Filename
+	lines, err := p.readLines(filename)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	type function struct {
+		nakedret.Finding
+		returns []int
+		// rules are the IDs of the rules reporting the naked returns.
+		rules []string
+	}
+	var functions []*function
+	var others []nakedret.Finding
+	byPos := make(map[int]*function)
+	for _, f := range findings {
+		if !slices.Contains(nakedReturnRules, f.Rule) {
+			// Findings about directives, named results or explicit
+			// returns rather than naked returns.
+			others = append(others, f)
+			continue
+		}
+		fn, ok := byPos[f.FuncPos.Offset]
+		if !ok {
+			fn = &function{Finding: f}
+			byPos[f.FuncPos.Offset] = fn
+			functions = append(functions, fn)
+		}
+		if !slices.Contains(fn.rules, f.Rule) {
+			fn.rules = append(fn.rules, f.Rule)
+		}
+		if len(f.Related) == 0 {
+			fn.returns = append(fn.returns, f.Pos.Line)
+		}
+		for _, rel := range f.Related {
+			fn.returns = append(fn.returns, rel.Pos.Line)
+		}
+	}
+
+	fmt.Fprintln(p.w, p.style(ansiBold, filename))
+	for _, f := range others {
+		fmt.Fprintf(p.w, "  line %d: %s [%s]\n", f.Pos.Line, p.style(ansiYellow, f.Message), f.Rule)
+	}
+	for _, fn := range functions {
+		fmt.Fprintf(p.w, "  func %s (line %d): %d lines (limit %d), %s [%s]\n",
+			p.style(ansiYellow, fn.Func), fn.FuncPos.Line, fn.Length, fn.MaxLength, plural(len(fn.returns), "naked return"), strings.Join(fn.rules, ", "))
+		p.printSnippet(lines, fn.returns)
+		returns += len(fn.returns)
+	}
+	fmt.Fprintln(p.w)
+	return returns, len(functions), nil
+}
+
+// printSnippet writes the given lines, each preceded by one line of context.
+func (p *prettyPrinter) printSnippet(lines []string, returns []int) {
+	width := len(fmt.Sprint(returns[len(returns)-1]))
+	isReturn := make(map[int]bool)
+	for _, line := range returns {
+		isReturn[line] = true
+	}
+
+	last := 0
+	for _, ret := range returns {
+		for line := max(ret-1, last+1, 1); line <= ret && line <= len(lines); line++ {
+			if last != 0 && line > last+1 {
+				fmt.Fprintf(p.w, "    %*s\n", width+2, "...")
+			}
+			text := fmt.Sprintf("%*d | %s", width, line, lines[line-1])
+			if isReturn[line] {
+				fmt.Fprintf(p.w, "  %s\n", p.style(ansiRed, "> "+text))
+			} else {
+				fmt.Fprintf(p.w, "    %s\n", p.style(ansiDim, text))
+			}
+			last = line
+		}
+	}
+}
+
+func (p *prettyPrinter) readLines(filename string) ([]string, error) {
+	src, ok := p.overlay[filename]
+	if !ok {
+		var err error
+		if src, err = os.ReadFile(filename); err != nil {
+			return nil, err
+		}
+	}
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, nil
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+const prettySrc = `package x
+
+func Many() (x int, err error) {
+	switch {
+	case true:
+		return
+	}
+	x = 1
+	x++
+	return
+}
+`
+
+func TestPrettyPrinter(t *testing.T) {
+	overlay := map[string][]byte{"x.go": []byte(prettySrc)}
+	findings, err := nakedret.Check([]string{"x.go"}, nakedret.Options{
+		NakedReturnRunner: nakedret.NakedReturnRunner{MaxLength: 5},
+		Overlay:           overlay,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	p := &prettyPrinter{w: &b, overlay: overlay}
+	if err := p.print(findings); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"x.go",
+		"  func Many (line 3): 8 lines (limit 5), 2 naked returns [NR001]",
+		"     5 | \tcase true:",
+		"  >  6 | \t\treturn",
+		"     ...",
+		"     9 | \tx++",
+		"  > 10 | \treturn",
+		"",
+		"2 naked returns in 1 function",
+		"",
+	}, "\n")
+	if b.String() != expected {
+		t.Errorf("Unexpected output:\n-----\ngot: \n%s\nexpected: \n%s\n-----\n", b.String(), expected)
+	}
+
+	b.Reset()
+	p.color = true
+	if err := p.print(findings); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), ansiRed+">  6 | \t\treturn"+ansiReset) {
+		t.Errorf("expected highlighted return line, got:\n%q", b.String())
+	}
+}
+
+func TestPrettyPrinterShadowingResults(t *testing.T) {
+	const src = `package x
+
+var count = 1
+
+func Count() (count int) {
+	count = 1
+	return
+}
+`
+	overlay := map[string][]byte{"x.go": []byte(src)}
+	findings, err := nakedret.Check([]string{"x.go"}, nakedret.Options{
+		NakedReturnRunner: nakedret.NakedReturnRunner{MaxLength: 1, ReportShadowingResults: true},
+		Overlay:           overlay,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	p := &prettyPrinter{w: &b, overlay: overlay}
+	if err := p.print(findings); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"x.go",
+		"  line 5: named result `count` of func `Count` shadows the package-level var `count` [NR008]",
+		"  func Count (line 5): 3 lines (limit 1), 1 naked return [NR001]",
+		"    6 | \tcount = 1",
+		"  > 7 | \treturn",
+		"",
+		"1 naked return in 1 function",
+		"",
+	}, "\n")
+	if b.String() != expected {
+		t.Errorf("Unexpected output:\n-----\ngot: \n%s\nexpected: \n%s\n-----\n", b.String(), expected)
+	}
+}
+
+func TestPrettyPrinterOtherRules(t *testing.T) {
+	const src = `package x
+
+func Parse(s string) (n int, err error) {
+	n = len(s)
+	return n, err
+}
+
+func Unused() (n int) {
+	return 1
+}
+`
+	overlay := map[string][]byte{"x.go": []byte(src)}
+	three := uint(3)
+	findings, err := nakedret.Check([]string{"x.go"}, nakedret.Options{
+		NakedReturnRunner: nakedret.NakedReturnRunner{MaxLength: 5, PreferNakedUnder: &three, ReportUnusedResults: true},
+		Overlay:           overlay,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	p := &prettyPrinter{w: &b, overlay: overlay}
+	if err := p.print(findings); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"x.go",
+		"  line 5: explicit return of the named results in func `Parse` with 3 lines of code [NR006]",
+		"  line 8: named result `n` of func `Unused` is never used [NR007]",
+		"",
+		"",
+	}, "\n")
+	if b.String() != expected {
+		t.Errorf("Unexpected output:\n-----\ngot: \n%s\nexpected: \n%s\n-----\n", b.String(), expected)
+	}
+}
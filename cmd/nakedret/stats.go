@@ -0,0 +1,74 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+// lengthBuckets are the upper bounds of the buckets of the function length
+// histogram. The last bucket holds the longer functions.
+var lengthBuckets = []int{5, 10, 25, 50, 100}
+
+const histogramWidth = 40
+
+// printStats writes the statistics of each package, with the top longest
+// functions using naked returns.
+func printStats(w io.Writer, stats []nakedret.PackageStats, top int) {
+	for _, s := range stats {
+		fmt.Fprintf(w, "package %s (%s)\n", s.Name, s.Dir)
+		fmt.Fprintf(w, "  functions:                    %d\n", len(s.Funcs))
+		fmt.Fprintf(w, "  functions with named results: %d\n", s.NamedResults)
+		fmt.Fprintf(w, "  functions with naked returns: %d\n", s.NakedReturns)
+		fmt.Fprintf(w, "  functions over the limit:     %d\n", s.OverLimit)
+
+		var naked []nakedret.FuncStats
+		for _, f := range s.Funcs {
+			if f.NakedReturns > 0 {
+				naked = append(naked, f)
+			}
+		}
+		if len(naked) == 0 {
+			fmt.Fprintln(w)
+			continue
+		}
+
+		fmt.Fprintln(w, "  lengths of functions with naked returns:")
+		counts := make([]int, len(lengthBuckets)+1)
+		for _, f := range naked {
+			i, _ := slices.BinarySearch(lengthBuckets, f.Length)
+			counts[i]++
+		}
+		maxCount := slices.Max(counts)
+		for i, count := range counts {
+			bar := strings.Repeat("#", (count*histogramWidth+maxCount-1)/maxCount)
+			fmt.Fprintf(w, "    %8s | %-*s %d\n", bucketLabel(i), histogramWidth, bar, count)
+		}
+
+		slices.SortStableFunc(naked, func(a, b nakedret.FuncStats) int {
+			return cmp.Compare(b.Length, a.Length)
+		})
+		naked = naked[:max(min(top, len(naked)), 0)]
+		fmt.Fprintf(w, "  longest functions with naked returns:\n")
+		for _, f := range naked {
+			fmt.Fprintf(w, "    %4d lines  %s (%s:%d)\n", f.Length, f.Name, filepath.Base(f.Pos.Filename), f.Pos.Line)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func bucketLabel(i int) string {
+	if i == len(lengthBuckets) {
+		return fmt.Sprintf("%d+", lengthBuckets[i-1]+1)
+	}
+	low := 1
+	if i > 0 {
+		low = lengthBuckets[i-1] + 1
+	}
+	return fmt.Sprintf("%d-%d", low, lengthBuckets[i])
+}
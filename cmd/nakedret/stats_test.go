@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+func TestPrintStats(t *testing.T) {
+	fn := func(name string, line, length, naked int) nakedret.FuncStats {
+		return nakedret.FuncStats{
+			Name:         name,
+			Pos:          token.Position{Filename: "dir/x.go", Line: line},
+			Length:       length,
+			MaxLength:    5,
+			NamedResults: true,
+			NakedReturns: naked,
+		}
+	}
+	stats := []nakedret.PackageStats{{
+		Dir:          "dir",
+		Name:         "x",
+		NamedResults: 4,
+		NakedReturns: 3,
+		OverLimit:    2,
+		Funcs: []nakedret.FuncStats{
+			fn("A", 1, 3, 1),
+			fn("B", 10, 60, 2),
+			fn("C", 80, 12, 1),
+			fn("D", 100, 200, 0),
+		},
+	}}
+
+	var b bytes.Buffer
+	printStats(&b, stats, 2)
+	expected := strings.Join([]string{
+		"package x (dir)",
+		"  functions:                    4",
+		"  functions with named results: 4",
+		"  functions with naked returns: 3",
+		"  functions over the limit:     2",
+		"  lengths of functions with naked returns:",
+		"         1-5 | " + strings.Repeat("#", 40) + " 1",
+		"        6-10 | " + strings.Repeat(" ", 40) + " 0",
+		"       11-25 | " + strings.Repeat("#", 40) + " 1",
+		"       26-50 | " + strings.Repeat(" ", 40) + " 0",
+		"      51-100 | " + strings.Repeat("#", 40) + " 1",
+		"        101+ | " + strings.Repeat(" ", 40) + " 0",
+		"  longest functions with naked returns:",
+		"      60 lines  B (x.go:10)",
+		"      12 lines  C (x.go:80)",
+		"",
+		"",
+	}, "\n")
+	if b.String() != expected {
+		t.Errorf("Unexpected output:\n-----\ngot: \n%s\nexpected: \n%s\n-----\n", b.String(), expected)
+	}
+
+	// A negative number of functions lists none rather than panicking.
+	b.Reset()
+	printStats(&b, stats, -1)
+	if !strings.HasSuffix(b.String(), "  longest functions with naked returns:\n\n") {
+		t.Errorf("Unexpected output with a negative top:\n%s", b.String())
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+// defaultThresholds are the candidate values of -l evaluated by suggest.
+const defaultThresholds = "0,5,10,15,25,50"
+
+// suggestion is the outcome of running nakedret with a given maximum length.
+type suggestion struct {
+	threshold uint
+	// findings is the number of naked returns reported and funcs the number
+	// of functions they belong to.
+	findings int
+	funcs    int
+	// percentile is the percentage of functions with naked returns that are
+	// not longer than the threshold.
+	percentile float64
+}
+
+// runSuggest prints how many findings each candidate threshold produces on args.
+func runSuggest(nakedRet *nakedret.NakedReturnRunner, analyzerFlags *flag.FlagSet, args []string) {
+	log.SetFlags(0)
+	log.SetPrefix("nakedret: ")
+
+	fs := flag.NewFlagSet("nakedret suggest", flag.ExitOnError)
+	analyzerFlags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	thresholdList := fs.String("thresholds", defaultThresholds, "comma separated candidate values of -l")
+	fs.Parse(args)
+
+	thresholds, err := parseThresholds(*thresholdList)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := nakedret.Options{NakedReturnRunner: *nakedRet}
+	stats, err := nakedret.Stats(fs.Args(), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	suggestions, err := suggest(fs.Args(), opts, stats, thresholds)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printSuggestions(os.Stdout, stats, suggestions)
+}
+
+func parseThresholds(list string) ([]uint, error) {
+	var thresholds []uint
+	for _, s := range strings.Split(list, ",") {
+		t, err := strconv.ParseUint(strings.TrimSpace(s), 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q", s)
+		}
+		thresholds = append(thresholds, uint(t))
+	}
+	return thresholds, nil
+}
+
+// suggest evaluates each threshold by checking patterns with it as the
+// maximum length, so that directives, ignore comments and the other options
+// apply as they do to the check. Only the findings of naked returns are
+// counted, out of the functions with naked returns in stats.
+func suggest(patterns []string, opts nakedret.Options, stats []nakedret.PackageStats, thresholds []uint) ([]suggestion, error) {
+	total := 0
+	for _, s := range stats {
+		total += s.NakedReturns
+	}
+
+	var suggestions []suggestion
+	for _, t := range thresholds {
+		opts.MaxLength = t
+		findings, err := nakedret.Check(patterns, opts)
+		if err != nil {
+			return nil, err
+		}
+		s := suggestion{threshold: t}
+		funcs := make(map[nakedret.Position]bool)
+		for _, f := range findings {
+			if slices.Contains(nakedReturnRules, f.Rule) {
+				s.findings++
+				funcs[f.FuncPos] = true
+			}
+		}
+		s.funcs = len(funcs)
+		if total > 0 {
+			s.percentile = 100 * float64(total-s.funcs) / float64(total)
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, nil
+}
+
+func printSuggestions(w io.Writer, stats []nakedret.PackageStats, suggestions []suggestion) {
+	funcs := 0
+	for _, s := range stats {
+		funcs += s.NakedReturns
+	}
+	fmt.Fprintf(w, "%s with naked returns in %s\n\n", plural(funcs, "function"), plural(len(stats), "package"))
+	fmt.Fprintf(w, "%10s %10s %10s %11s\n", "-l", "findings", "functions", "percentile")
+	for _, s := range suggestions {
+		fmt.Fprintf(w, "%10d %10d %10d %10.1f%%\n", s.threshold, s.findings, s.funcs, s.percentile)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+func TestSuggestMatchesCheck(t *testing.T) {
+	args := []string{"../../testdata/src/x"}
+	stats, err := nakedret.Stats(args, nakedret.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thresholds, err := parseThresholds(defaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suggestions, err := suggest(args, nakedret.Options{}, stats, thresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range suggestions {
+		findings, err := nakedret.Check(args, nakedret.Options{NakedReturnRunner: nakedret.NakedReturnRunner{MaxLength: s.threshold}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.findings != len(findings) {
+			t.Errorf("-l %d: suggested %d findings, check reported %d", s.threshold, s.findings, len(findings))
+		}
+	}
+}
+
+const suggestSrc = `package x
+
+//nakedret:max-length 50
+func Directive() (err error) {
+	err = nil
+	err = nil
+	err = nil
+	err = nil
+	return
+}
+
+func Ignored() (err error) {
+	err = nil
+	err = nil
+	err = nil
+	err = nil
+	return //nakedret:ignore
+}
+
+func Long() (err error) {
+	err = nil
+	err = nil
+	err = nil
+	if err != nil {
+		return
+	}
+	return
+}
+
+func Short() (err error) {
+	return
+}
+`
+
+func TestSuggest(t *testing.T) {
+	args := []string{"x.go"}
+	opts := nakedret.Options{Overlay: map[string][]byte{"x.go": []byte(suggestSrc)}}
+	stats, err := nakedret.Stats(args, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suggestions, err := suggest(args, opts, stats, []uint{0, 5, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []suggestion{
+		{threshold: 0, findings: 3, funcs: 2, percentile: 50},
+		{threshold: 5, findings: 2, funcs: 1, percentile: 75},
+		{threshold: 10, findings: 0, funcs: 0, percentile: 100},
+	}
+	for i, s := range suggestions {
+		if s != expected[i] {
+			t.Errorf("got %+v, expected %+v", s, expected[i])
+		}
+	}
+}
+
+func TestParseThresholds(t *testing.T) {
+	if _, err := parseThresholds("5,ten"); err == nil {
+		t.Error("expected an error for an invalid threshold")
+	}
+	thresholds, err := parseThresholds(" 1, 2 ,3")
+	if err != nil || len(thresholds) != 3 || thresholds[2] != 3 {
+		t.Errorf("unexpected thresholds %v (%v)", thresholds, err)
+	}
+}
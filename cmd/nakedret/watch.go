@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+// runWatch prints the findings in args, then the ones added and resolved by
+// every change to the files, until interrupted.
+func runWatch(opts nakedret.Options, args []string) {
+	log.SetFlags(0)
+	log.SetPrefix("nakedret: ")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := nakedret.Watch(ctx, args, opts, func(added, resolved []nakedret.Finding) {
+		for _, f := range resolved {
+			fmt.Printf("- %s:%d: %s (%s)\n", f.Pos.Filename, f.Pos.Line, f.Message, f.Rule)
+		}
+		for _, f := range added {
+			fmt.Printf("+ %s:%d: %s (%s)\n", f.Pos.Filename, f.Pos.Line, f.Message, f.Rule)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,132 @@
+package nakedret
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// DeferMode sets how naked returns are reported in functions whose named
+// results are assigned in a deferred function literal, as when recovering
+// from a panic, which requires named results.
+type DeferMode int
+
+const (
+	// DeferReport reports these functions like the others.
+	DeferReport DeferMode = iota
+	// DeferAnnotate reports them like the others, mentioning the line of
+	// the deferred function literal in the message.
+	DeferAnnotate
+	// DeferExempt does not report them for their length.
+	DeferExempt
+	// DeferThreshold applies NakedReturnRunner.DeferMaxLength to them.
+	DeferThreshold
+)
+
+var deferModeNames = [...]string{
+	DeferReport:    "report",
+	DeferAnnotate:  "annotate",
+	DeferExempt:    "exempt",
+	DeferThreshold: "threshold",
+}
+
+func (m DeferMode) String() string {
+	if m < 0 || int(m) >= len(deferModeNames) {
+		return fmt.Sprintf("DeferMode(%d)", int(m))
+	}
+	return deferModeNames[m]
+}
+
+// Set implements flag.Value.
+func (m *DeferMode) Set(name string) error {
+	for i, n := range deferModeNames {
+		if n == name {
+			*m = DeferMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown defer mode %q", name)
+}
+
+func (m DeferMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *DeferMode) UnmarshalText(text []byte) error {
+	return m.Set(string(text))
+}
+
+// deferredResults returns the position of the first function literal
+// deferred in body that assigns one of the named results of funcType, or
+// token.NoPos. The defers of the literals nested in body are ignored as they
+// apply to the results of these literals.
+func deferredResults(funcType *ast.FuncType, body *ast.BlockStmt) token.Pos {
+	if body == nil {
+		return token.NoPos
+	}
+	results := make(map[string]bool)
+	for _, field := range funcType.Results.List {
+		for _, name := range field.Names {
+			results[name.Name] = true
+		}
+	}
+
+	pos := token.NoPos
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			if lit, ok := ast.Unparen(n.Call.Fun).(*ast.FuncLit); ok && assignsAny(lit, results) {
+				pos = lit.Pos()
+			}
+			return false
+		}
+		return !pos.IsValid()
+	})
+	return pos
+}
+
+// assignsAny reports whether the body of lit assigns one of names, unless
+// they are shadowed by the parameters or results of lit.
+func assignsAny(lit *ast.FuncLit, names map[string]bool) bool {
+	visible := make(map[string]bool)
+	for name := range names {
+		visible[name] = true
+	}
+	for _, list := range []*ast.FieldList{lit.Type.Params, lit.Type.Results} {
+		if list == nil {
+			continue
+		}
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				delete(visible, name.Name)
+			}
+		}
+	}
+
+	assigned := false
+	isVisible := func(expr ast.Expr) bool {
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && visible[ident.Name]
+	}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				break
+			}
+			for _, lhs := range n.Lhs {
+				if isVisible(lhs) {
+					assigned = true
+				}
+			}
+		case *ast.IncDecStmt:
+			if isVisible(n.X) {
+				assigned = true
+			}
+		}
+		return !assigned
+	})
+	return assigned
+}
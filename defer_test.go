@@ -0,0 +1,55 @@
+package nakedret
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestDeferredResults(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(wd, "testdata")
+	analysistest.Run(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: 1, DeferredResults: DeferAnnotate}), "deferred")
+}
+
+func TestDeferredResultsLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		runner   NakedReturnRunner
+		expected []string
+	}{
+		{"report", NakedReturnRunner{MaxLength: 1}, []string{"Recovered", "Shadowed", "Nested.f", "Nested", "Plain"}},
+		{"exempt", NakedReturnRunner{MaxLength: 1, DeferredResults: DeferExempt, ReportShort: true}, []string{"Shadowed", "Nested", "Plain"}},
+		{"threshold", NakedReturnRunner{MaxLength: 1, DeferredResults: DeferThreshold, DeferMaxLength: 5}, []string{"Recovered", "Shadowed", "Nested", "Plain"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, f := range checkFindings(t, tt.runner, "testdata/src/deferred") {
+				names = append(names, f.Func)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("got %q, expected %q", names, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeferModeText(t *testing.T) {
+	for _, m := range []DeferMode{DeferReport, DeferAnnotate, DeferExempt, DeferThreshold} {
+		var parsed DeferMode
+		if err := parsed.Set(m.String()); err != nil || parsed != m {
+			t.Errorf("Set(%q) = %v, %v", m, parsed, err)
+		}
+	}
+	var m DeferMode
+	if err := m.UnmarshalText([]byte("ignore")); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,175 @@
+package nakedret
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// directivePrefix starts the comments configuring nakedret in the source.
+const directivePrefix = "//nakedret:"
+
+// Names of the directives.
+const (
+	// ignoreDirective suppresses findings reported on the line of the
+	// comment or on the next one. It is followed by a comma separated list
+	// of the rule IDs to suppress, and optionally a reason, e.g.
+	//
+	//	//nakedret:ignore NR001 generated code
+	//
+	// Without rule IDs, all the findings of these lines are suppressed.
+	ignoreDirective = "ignore"
+	// maxLengthDirective replaces the maximum length of the function whose
+	// doc comment it is in, along with the literals nested in it. Before the
+	// package clause, it applies to the functions of the file or, in doc.go,
+	// of the package. It is followed by the number of lines and optionally a
+	// reason, e.g.
+	//
+	//	//nakedret:max-length 20 table driven
+	maxLengthDirective = "max-length"
+)
+
+// docFile is the name of the file whose max-length directives before the
+// package clause apply to the whole package.
+const docFile = "doc.go"
+
+// Directive is a //nakedret: comment.
+type Directive struct {
+	Pos token.Position
+	// Name is the name of the directive, e.g. "max-length", and Args the
+	// text following it.
+	Name string
+	Args string
+	// Scope is what the directive applies to: "line", "file", "package" or
+	// "func " followed by the name of a function.
+	Scope string
+	// Err describes why the directive is invalid and ignored, if it is.
+	Err string
+}
+
+// directive is a parsed //nakedret: comment.
+type directive struct {
+	comment    *ast.Comment
+	name, args string
+	scope      string
+	// rules are the rules of an ignore directive, nil for all of them.
+	rules []string
+	// maxLength is the length of a max-length directive.
+	maxLength uint
+	err       error
+}
+
+// fileDirectives holds the directives of a file.
+type fileDirectives struct {
+	all     []directive
+	ignores ignores
+	// maxLength is the length set before the package clause, if any, and
+	// funcMaxLengths the lengths set in the doc comments of functions.
+	maxLength      *uint
+	funcMaxLengths map[*ast.FuncDecl]uint
+}
+
+func parseDirectives(fset *token.FileSet, file *ast.File) fileDirectives {
+	docs := make(map[*ast.CommentGroup]*ast.FuncDecl)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Doc != nil {
+			docs[fn.Doc] = fn
+		}
+	}
+	fileScope := "file"
+	if filepath.Base(fset.File(file.Pos()).Name()) == docFile {
+		fileScope = "package"
+	}
+
+	fd := fileDirectives{ignores: make(ignores), funcMaxLengths: make(map[*ast.FuncDecl]uint)}
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			text, ok := strings.CutPrefix(c.Text, directivePrefix)
+			if !ok {
+				continue
+			}
+			d := directive{comment: c, name: text}
+			if i := strings.IndexAny(text, " \t"); i >= 0 {
+				d.name, d.args = text[:i], strings.TrimSpace(text[i:])
+			}
+			fields := strings.Fields(d.args)
+
+			switch d.name {
+			case ignoreDirective:
+				d.scope = "line"
+				if len(fields) > 0 {
+					d.rules, d.err = parseRules(fields[0])
+				}
+				if d.err == nil {
+					line := fset.Position(c.Pos()).Line
+					fd.ignores.add(line, d.rules)
+					fd.ignores.add(line+1, d.rules)
+				}
+			case maxLengthDirective:
+				var n uint64
+				if len(fields) == 0 {
+					d.err = fmt.Errorf("missing number of lines")
+				} else if n, d.err = strconv.ParseUint(fields[0], 10, strconv.IntSize); d.err != nil {
+					d.err = fmt.Errorf("invalid number of lines %q", fields[0])
+				}
+				d.maxLength = uint(n)
+				switch fn := docs[group]; {
+				case c.Pos() < file.Package:
+					d.scope = fileScope
+					if d.err == nil {
+						fd.maxLength = &d.maxLength
+					}
+				case fn != nil:
+					d.scope = "func " + funcDeclName(fn)
+					if d.err == nil {
+						fd.funcMaxLengths[fn] = d.maxLength
+					}
+				case d.err == nil:
+					d.err = fmt.Errorf("not in the doc comment of a function or before the package clause")
+				}
+			default:
+				d.err = fmt.Errorf("unknown directive")
+			}
+			fd.all = append(fd.all, d)
+		}
+	}
+	return fd
+}
+
+// Directives returns the //nakedret: comments of the files, directories or
+// packages named by patterns, sorted by position.
+func Directives(patterns []string, opts Options) ([]Directive, error) {
+	fset := token.NewFileSet()
+	files, _, err := opts.parseInput(patterns, fset)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse input: %v", err)
+	}
+
+	var directives []Directive
+	for _, file := range files {
+		for _, d := range parseDirectives(fset, file).all {
+			directive := Directive{
+				Pos:   fset.Position(d.comment.Pos()),
+				Name:  d.name,
+				Args:  d.args,
+				Scope: d.scope,
+			}
+			if d.err != nil {
+				directive.Err = d.err.Error()
+			}
+			directives = append(directives, directive)
+		}
+	}
+	slices.SortFunc(directives, func(a, b Directive) int {
+		return cmp.Or(
+			cmp.Compare(a.Pos.Filename, b.Pos.Filename),
+			cmp.Compare(a.Pos.Offset, b.Pos.Offset),
+		)
+	})
+	return directives, nil
+}
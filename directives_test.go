@@ -0,0 +1,139 @@
+package nakedret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var directivesTree = map[string]string{
+	"doc.go": `//nakedret:max-length 4
+
+// Package p has a package wide limit.
+package p
+`,
+	"a.go": `package p
+
+func Package() (err error) {
+	_ = 0
+	_ = 0
+	_ = 0
+	return
+}
+
+// Strict has its own limit, which applies to its literals too.
+//
+//nakedret:max-length 0
+func Strict() (err error) {
+	f := func() (err error) {
+		return
+	}
+	_ = f
+	return
+}
+
+func Invalid() (err error) {
+	//nakedret:max-length 100
+	return
+}
+`,
+	"b.go": `//nakedret:max-length 1
+
+package p
+
+func File() (err error) {
+	_ = 0
+	return
+}
+
+//nakedret:max-length ten
+func BadNumber() (err error) { return }
+
+//nakedret:maxlength 2
+func Unknown() (err error) { return }
+`,
+}
+
+func writeDirectivesTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range directivesTree {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func directiveFindings(t *testing.T, dir string, opts Options) string {
+	t.Helper()
+	findings, err := Check([]string{dir}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, filepath.Base(f.Pos.Filename)+": "+f.Rule+" "+f.Message)
+	}
+	return strings.Join(got, "\n")
+}
+
+func TestMaxLengthDirectives(t *testing.T) {
+	dir := writeDirectivesTree(t)
+	got := directiveFindings(t, dir, Options{NakedReturnRunner: NakedReturnRunner{MaxLength: 100}})
+	expected := strings.Join([]string{
+		"a.go: NR001 naked return in func `Package` with 5 lines of code",
+		"a.go: NR001 naked return in func `Strict.f` with 2 lines of code",
+		"a.go: NR001 naked return in func `Strict` with 6 lines of code",
+		"a.go: NR004 invalid directive `//nakedret:max-length 100`: not in the doc comment of a function or before the package clause",
+		"b.go: NR001 naked return in func `File` with 3 lines of code",
+		"b.go: NR004 invalid directive `//nakedret:max-length ten`: invalid number of lines \"ten\"",
+		"b.go: NR004 invalid directive `//nakedret:maxlength 2`: unknown directive",
+	}, "\n")
+	if got != expected {
+		t.Errorf("Unexpected findings:\n-----\ngot: \n%s\nexpected: \n%s\n-----\n", got, expected)
+	}
+}
+
+func TestDirectivesCache(t *testing.T) {
+	dir := writeDirectivesTree(t)
+	opts := Options{NakedReturnRunner: NakedReturnRunner{MaxLength: 100}, CacheDir: t.TempDir()}
+	first := directiveFindings(t, dir, opts)
+	if cached := directiveFindings(t, dir, opts); cached != first {
+		t.Errorf("cached findings differ:\n%s\n-----\n%s", cached, first)
+	}
+
+	// Raising the package limit must change the findings of a.go.
+	doc := strings.Replace(directivesTree["doc.go"], "max-length 4", "max-length 40", 1)
+	if err := os.WriteFile(filepath.Join(dir, "doc.go"), []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := directiveFindings(t, dir, opts)
+	if strings.Contains(got, "`Package`") {
+		t.Errorf("stale findings after changing doc.go:\n%s", got)
+	}
+}
+
+func TestListDirectives(t *testing.T) {
+	dir := writeDirectivesTree(t)
+	directives, err := Directives([]string{dir}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range directives {
+		got = append(got, filepath.Base(d.Pos.Filename)+" "+d.Name+" "+d.Args+" ("+d.Scope+") "+d.Err)
+	}
+	expected := []string{
+		"a.go max-length 0 (func Strict) ",
+		"a.go max-length 100 () not in the doc comment of a function or before the package clause",
+		"b.go max-length 1 (file) ",
+		"b.go max-length ten (func BadNumber) invalid number of lines \"ten\"",
+		"b.go maxlength 2 () unknown directive",
+		"doc.go max-length 4 (package) ",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,73 @@
+package nakedret
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// RegisterFlags defines flags in fs setting the fields of n, with their
+// current values as defaults.
+func (n *NakedReturnRunner) RegisterFlags(fs *flag.FlagSet) {
+	fs.UintVar(&n.MaxLength, "l", n.MaxLength, "maximum number of lines for a naked return function")
+	fs.Var(optionalUint{&n.ExportedMaxLength}, "l-exported", "maximum number of lines for a naked return exported function (default: -l)")
+	fs.Var(optionalUint{&n.ExportedMethodMaxLength}, "l-exported-methods", "maximum number of lines for a naked return exported method of an exported type (default: -l)")
+	fs.Var(optionalUint{&n.UnexportedMaxLength}, "l-unexported", "maximum number of lines for a naked return unexported function or method (default: -l)")
+	fs.Var(optionalUint{&n.LiteralMaxLength}, "l-literals", "maximum number of lines for a naked return function literal (default: -l)")
+	fs.BoolVar(&n.SkipTestFiles, "skip-test-files", n.SkipTestFiles, "set to true to skip test files")
+	fs.BoolVar(&n.SkipMain, "skip-main", n.SkipMain, "skip the files of main packages")
+	fs.BoolVar(&n.GroupByFunction, "group-by-function", n.GroupByFunction, "report a single diagnostic per function listing its naked returns")
+	fs.BoolVar(&n.LineLiteralNames, "line-literal-names", n.LineLiteralNames, "name function literals after their line, e.g. <func():42>, as older versions did")
+	fs.Var(&n.DeferredResults, "deferred-results", "how to report functions whose named results are assigned in a deferred function literal: report, annotate, exempt or threshold (see -l-deferred)")
+	fs.UintVar(&n.DeferMaxLength, "l-deferred", n.DeferMaxLength, "maximum number of lines for a naked return function whose named results are assigned in a deferred function literal, with -deferred-results=threshold")
+	fs.BoolVar(&n.PerReturn, "per-return", n.PerReturn, "compare -l to the number of lines from the start of the function to each naked return instead of its length")
+	fs.Var(optionalUint{&n.MaxDepth}, "max-depth", "maximum number of if, for, switch and select statements a naked return can be nested in (default: unlimited)")
+	fs.Var(optionalUint{&n.PreferNakedUnder}, "prefer-naked-under", "report explicit returns of the named results, in order, in functions of at most this number of lines, with a fix making them naked (default: disabled)")
+	fs.BoolVar(&n.ReportUnusedResults, "unused-results", n.ReportUnusedResults, "report named results only used by naked returns, if at all, with a fix removing their names")
+	fs.BoolVar(&n.ReportShadowingResults, "shadowing-results", n.ReportShadowingResults, "report named results shadowing a builtin, an imported package or a package-level declaration")
+	fs.BoolVar(&n.ReportShort, "report-short", n.ReportShort, "also report the naked returns of short exported functions, as info")
+	fs.Var(ruleList{&n.DisabledRules}, "disable", "comma separated IDs of the rules not to report, e.g. NR003")
+}
+
+// ruleList is a flag setting a list of rule IDs.
+type ruleList struct {
+	p *[]string
+}
+
+func (l ruleList) String() string {
+	if l.p == nil {
+		return ""
+	}
+	return strings.Join(*l.p, ",")
+}
+
+func (l ruleList) Set(s string) error {
+	ids, err := parseRules(s)
+	if err != nil {
+		return err
+	}
+	*l.p = ids
+	return nil
+}
+
+// optionalUint is a flag setting a uint that is nil unless the flag is used.
+type optionalUint struct {
+	p **uint
+}
+
+func (o optionalUint) String() string {
+	if o.p == nil || *o.p == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(**o.p), 10)
+}
+
+func (o optionalUint) Set(s string) error {
+	v, err := strconv.ParseUint(s, 0, strconv.IntSize)
+	if err != nil {
+		return err
+	}
+	u := uint(v)
+	*o.p = &u
+	return nil
+}
@@ -4,9 +4,14 @@ go 1.23.0
 
 toolchain go1.24.0
 
-require golang.org/x/tools v0.31.0
+require (
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/golangci/plugin-module-register v0.1.1
+	golang.org/x/tools v0.31.0
+)
 
 require (
 	golang.org/x/mod v0.24.0 // indirect
 	golang.org/x/sync v0.12.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
 )
@@ -0,0 +1,24 @@
+package nakedret
+
+import "slices"
+
+// ignores maps lines to the IDs of the rules suppressed on them by ignore
+// directives, nil meaning all of them.
+type ignores map[int][]string
+
+func (ign ignores) add(line int, rules []string) {
+	prev, ok := ign[line]
+	switch {
+	case ok && prev == nil:
+	case rules == nil:
+		ign[line] = nil
+	default:
+		ign[line] = append(prev, rules...)
+	}
+}
+
+// ignored reports whether findings of the rule are suppressed on line.
+func (ign ignores) ignored(line int, rule string) bool {
+	rules, ok := ign[line]
+	return ok && (rules == nil || slices.Contains(rules, rule))
+}
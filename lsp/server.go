@@ -0,0 +1,286 @@
+// Package lsp implements a minimal language server publishing nakedret
+// diagnostics and offering their suggested fixes as code actions.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"unicode/utf16"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+// ErrExitWithoutShutdown is returned by Serve when the client sent the exit
+// notification without a prior shutdown request.
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+// Server is a language server speaking LSP over a single connection.
+type Server struct {
+	opts nakedret.Options
+	conn *conn
+
+	// docs holds the contents of the open documents, by URI.
+	docs     map[string]string
+	shutdown bool
+}
+
+// NewServer returns a server checking documents with the given options.
+func NewServer(opts nakedret.Options) *Server {
+	return &Server{
+		opts: opts,
+		docs: make(map[string]string),
+	}
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends the exit notification or r is exhausted.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			var rerr *responseError
+			if errors.As(err, &rerr) {
+				if err := s.conn.reply(nil, nil, rerr); err != nil {
+					return err
+				}
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, rerr := s.handle(msg)
+		if msg.ID == nil {
+			if rerr != nil {
+				log.Printf("%s: %v", msg.Method, rerr)
+			}
+			continue
+		}
+		if err := s.conn.reply(msg.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (any, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: TextDocumentSyncOptions{
+					OpenClose: true,
+					Change:    TextDocumentSyncKindFull,
+					Save:      SaveOptions{IncludeText: true},
+				},
+				CodeActionProvider: CodeActionOptions{
+					CodeActionKinds: []string{CodeActionKindQuickFix},
+				},
+			},
+			ServerInfo: ServerInfo{Name: "nakedret"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if rerr := unmarshalParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return nil, s.publish(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if rerr := unmarshalParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+		return nil, nil
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if rerr := unmarshalParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		if params.Text != nil {
+			s.docs[params.TextDocument.URI] = *params.Text
+		}
+		return nil, s.publish(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if rerr := unmarshalParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.notifyDiagnostics(params.TextDocument.URI, []Diagnostic{})
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if rerr := unmarshalParams(msg, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.codeActions(params)
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", msg.Method)}
+}
+
+func unmarshalParams(msg *message, v any) *responseError {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// check runs nakedret over the current contents of the document.
+func (s *Server) check(uri string) (string, []nakedret.Finding, error) {
+	text, ok := s.docs[uri]
+	if !ok {
+		return "", nil, fmt.Errorf("document %s is not open", uri)
+	}
+	filename, err := uriToPath(uri)
+	if err != nil {
+		return "", nil, err
+	}
+
+	opts := s.opts
+	opts.Overlay = map[string][]byte{filename: []byte(text)}
+	findings, err := nakedret.Check([]string{filename}, opts)
+	return text, findings, err
+}
+
+func (s *Server) publish(uri string) *responseError {
+	text, findings, err := s.check(uri)
+	if err != nil {
+		// Keep the previous diagnostics around while the document does not parse.
+		log.Printf("checking %s: %v", uri, err)
+		return nil
+	}
+
+	diagnostics := []Diagnostic{}
+	for _, f := range findings {
+		diagnostics = append(diagnostics, toDiagnostic(text, f))
+	}
+	return s.notifyDiagnostics(uri, diagnostics)
+}
+
+func (s *Server) notifyDiagnostics(uri string, diagnostics []Diagnostic) *responseError {
+	err := s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+	if err != nil {
+		return &responseError{Code: codeInternalError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) codeActions(params CodeActionParams) (any, *responseError) {
+	uri := params.TextDocument.URI
+	text, findings, err := s.check(uri)
+	if err != nil {
+		return nil, &responseError{Code: codeInternalError, Message: err.Error()}
+	}
+
+	actions := []CodeAction{}
+	for _, f := range findings {
+		d := toDiagnostic(text, f)
+		if !d.Range.overlaps(params.Range) {
+			continue
+		}
+		title := "make return explicit"
+		switch {
+		case f.Rule == nakedret.RulePreferNaked:
+			title = "make return naked"
+		case f.Rule == nakedret.RuleUnusedResult:
+			title = "remove unused result names"
+		case len(f.Edits) > 1:
+			title = "make returns explicit"
+		}
+		var edits []TextEdit
+		for _, edit := range f.Edits {
+			edits = append(edits, TextEdit{
+				Range:   Range{Start: toPosition(text, edit.Pos), End: toPosition(text, edit.End)},
+				NewText: edit.NewText,
+			})
+		}
+		if len(edits) == 0 {
+			continue
+		}
+		actions = append(actions, CodeAction{
+			Title:       title,
+			Kind:        CodeActionKindQuickFix,
+			Diagnostics: []Diagnostic{d},
+			IsPreferred: true,
+			Edit: WorkspaceEdit{
+				Changes: map[string][]TextEdit{uri: edits},
+			},
+		})
+	}
+	return actions, nil
+}
+
+func toDiagnostic(text string, f nakedret.Finding) Diagnostic {
+	return Diagnostic{
+		Range: Range{
+			Start: toPosition(text, f.Pos),
+			End:   toPosition(text, f.End),
+		},
+		Severity:        toSeverity(f.Severity),
+		Code:            f.Rule,
+		CodeDescription: &CodeDescription{Href: nakedret.RuleURL(f.Rule)},
+		Source:          "nakedret",
+		Message:         f.Message,
+	}
+}
+
+func toSeverity(s nakedret.Severity) int {
+	switch s {
+	case nakedret.SeverityError:
+		return DiagnosticSeverityError
+	case nakedret.SeverityInfo:
+		return DiagnosticSeverityInformation
+	}
+	return DiagnosticSeverityWarning
+}
+
+// toPosition converts a byte based token position within text to an LSP position.
+func toPosition(text string, pos nakedret.Position) Position {
+	lineStart := pos.Offset - (pos.Column - 1)
+	return Position{
+		Line:      pos.Line - 1,
+		Character: len(utf16.Encode([]rune(text[lineStart:pos.Offset]))),
+	}
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q", u.Scheme)
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		// file:///C:/dir/file.go
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
@@ -0,0 +1,268 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+const src = `package x
+
+func Long() (err error) {
+	println("a")
+	println("b")
+	return
+}
+
+func Short() (err error) {
+	return
+}
+`
+
+// client is an in-process LSP client talking to a Server over pipes.
+type client struct {
+	t      *testing.T
+	conn   *conn
+	nextID int
+	done   chan error
+
+	// notifications received while waiting for responses.
+	notifications []*message
+}
+
+func startServer(t *testing.T, opts nakedret.Options) *client {
+	t.Helper()
+	serverR, clientW := io.Pipe()
+	clientR, serverW := io.Pipe()
+	c := &client{t: t, conn: newConn(clientR, clientW), done: make(chan error, 1)}
+	go func() {
+		err := NewServer(opts).Serve(serverR, serverW)
+		serverW.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() { clientW.Close() })
+	return c
+}
+
+func (c *client) call(method string, params, result any) {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(fmt.Sprint(c.nextID))
+	b, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.write(&message{ID: &id, Method: method, Params: b}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg, err := c.conn.read()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if string(*msg.ID) != string(id) {
+			c.t.Fatalf("got response to %s while waiting for %s", *msg.ID, id)
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: %v", method, msg.Error)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// diagnostics waits for the next publishDiagnostics notification.
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	var msg *message
+	if len(c.notifications) > 0 {
+		msg, c.notifications = c.notifications[0], c.notifications[1:]
+	} else {
+		var err error
+		if msg, err = c.conn.read(); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("unexpected notification %s", msg.Method)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func TestServer(t *testing.T) {
+	c := startServer(t, nakedret.Options{NakedReturnRunner: nakedret.NakedReturnRunner{MaxLength: 2}})
+	uri := pathToURI(filepath.Join(t.TempDir(), "x.go"))
+
+	var init InitializeResult
+	c.call("initialize", map[string]any{"capabilities": map[string]any{}}, &init)
+	if !init.Capabilities.TextDocumentSync.OpenClose || len(init.Capabilities.CodeActionProvider.CodeActionKinds) == 0 {
+		t.Fatalf("unexpected capabilities %+v", init.Capabilities)
+	}
+	c.notify("initialized", struct{}{})
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: src},
+	})
+	published := c.diagnostics()
+	if published.URI != uri {
+		t.Errorf("diagnostics published for %s, expected %s", published.URI, uri)
+	}
+	wantRange := Range{Start: Position{Line: 5, Character: 1}, End: Position{Line: 5, Character: 7}}
+	if len(published.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %+v", published.Diagnostics)
+	}
+	if d := published.Diagnostics[0]; d.Range != wantRange || d.Message != "naked return in func `Long` with 4 lines of code" {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+	if d := published.Diagnostics[0]; d.Code != "NR001" || d.CodeDescription == nil || d.Severity != DiagnosticSeverityWarning {
+		t.Errorf("unexpected code or severity in %+v", d)
+	}
+
+	var actions []CodeAction
+	c.call("textDocument/codeAction", CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Range:        Range{Start: Position{Line: 5, Character: 3}, End: Position{Line: 5, Character: 3}},
+	}, &actions)
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 code action, got %+v", actions)
+	}
+	edits := actions[0].Edit.Changes[uri]
+	if actions[0].Title != "make return explicit" || len(edits) != 1 || edits[0].Range != wantRange || edits[0].NewText != "return err" {
+		t.Errorf("unexpected code action %+v", actions[0])
+	}
+
+	c.call("textDocument/codeAction", CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Range:        Range{Start: Position{Line: 9, Character: 0}, End: Position{Line: 9, Character: 0}},
+	}, &actions)
+	if len(actions) != 0 {
+		t.Errorf("expected no code action outside of the diagnostic, got %+v", actions)
+	}
+
+	fixed := strings.Replace(src, "\treturn\n}\n\nfunc Short", "\treturn err\n}\n\nfunc Short", 1)
+	c.notify("textDocument/didSave", DidSaveTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Text:         &fixed,
+	})
+	if published := c.diagnostics(); len(published.Diagnostics) != 0 {
+		t.Errorf("expected diagnostics to be cleared, got %+v", published.Diagnostics)
+	}
+
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGroupedCodeAction(t *testing.T) {
+	c := startServer(t, nakedret.Options{NakedReturnRunner: nakedret.NakedReturnRunner{MaxLength: 2, GroupByFunction: true}})
+	uri := pathToURI(filepath.Join(t.TempDir(), "x.go"))
+	text := "package x\n\nfunc Long() (err error) {\n\tif true {\n\t\treturn\n\t}\n\treturn\n}\n"
+
+	c.call("initialize", map[string]any{"capabilities": map[string]any{}}, nil)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: text},
+	})
+	published := c.diagnostics()
+	nameRange := Range{Start: Position{Line: 2, Character: 5}, End: Position{Line: 2, Character: 9}}
+	if len(published.Diagnostics) != 1 || published.Diagnostics[0].Range != nameRange {
+		t.Fatalf("unexpected diagnostics %+v", published.Diagnostics)
+	}
+
+	var actions []CodeAction
+	c.call("textDocument/codeAction", CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Range:        nameRange,
+	}, &actions)
+	if len(actions) != 1 || actions[0].Title != "make returns explicit" {
+		t.Fatalf("unexpected code actions %+v", actions)
+	}
+	edits := actions[0].Edit.Changes[uri]
+	if len(edits) != 2 || edits[0].Range.Start.Line != 4 || edits[1].Range.Start.Line != 6 || edits[1].NewText != "return err" {
+		t.Errorf("unexpected edits %+v", edits)
+	}
+}
+
+func TestUnusedResultsCodeAction(t *testing.T) {
+	c := startServer(t, nakedret.Options{NakedReturnRunner: nakedret.NakedReturnRunner{MaxLength: 10, ReportUnusedResults: true}})
+	uri := pathToURI(filepath.Join(t.TempDir(), "x.go"))
+	text := "package x\n\nfunc F() (a, b int, err error) {\n\treturn\n}\n"
+
+	c.call("initialize", map[string]any{"capabilities": map[string]any{}}, nil)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: text},
+	})
+	published := c.diagnostics()
+	if len(published.Diagnostics) != 1 {
+		t.Fatalf("unexpected diagnostics %+v", published.Diagnostics)
+	}
+
+	var actions []CodeAction
+	c.call("textDocument/codeAction", CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Range:        published.Diagnostics[0].Range,
+	}, &actions)
+	if len(actions) != 1 || actions[0].Title != "remove unused result names" {
+		t.Fatalf("unexpected code actions %+v", actions)
+	}
+	// Each unused name is renamed on its own.
+	var got []string
+	for _, e := range actions[0].Edit.Changes[uri] {
+		got = append(got, fmt.Sprintf("%d:%d-%d:%s", e.Range.Start.Line, e.Range.Start.Character, e.Range.End.Character, e.NewText))
+	}
+	if want := "2:10-11:_ 2:13-14:_ 2:20-23:_"; strings.Join(got, " ") != want {
+		t.Errorf("got edits %q, expected %q", got, want)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := startServer(t, nakedret.Options{})
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrExitWithoutShutdown {
+		t.Fatalf("got %v, expected %v", err, ErrExitWithoutShutdown)
+	}
+}
+
+func TestToPosition(t *testing.T) {
+	text := "package x\n\nvar s = \"😀\"; var t = 1\n"
+	offset := strings.Index(text, "var t")
+	pos := nakedret.Position{Offset: offset, Line: 3, Column: offset - strings.LastIndex(text[:offset], "\n")}
+	// The emoji is 4 bytes in UTF-8 but 2 code units in UTF-16.
+	if got, want := toPosition(text, pos), (Position{Line: 2, Character: 14}); got != want {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+}
@@ -3,16 +3,23 @@ package nakedret
 import (
 	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/build"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"go/types"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
@@ -21,6 +28,25 @@ import (
 
 const pwd = "./"
 
+// DefaultMaxLength is the maximum length of functions with naked returns
+// used by Analyzer unless set with its -l flag.
+const DefaultMaxLength = 5
+
+// DefaultRunner is the configuration of Analyzer, set by its flags.
+var DefaultRunner = &NakedReturnRunner{MaxLength: DefaultMaxLength}
+
+// Analyzer reports naked returns as configured by DefaultRunner. Its Flags
+// are those defined by RegisterFlags, for drivers that configure analyzers
+// through their flags. Use NakedReturnAnalyzer for other configurations.
+var Analyzer = newDefaultAnalyzer()
+
+func newDefaultAnalyzer() *analysis.Analyzer {
+	a := NakedReturnAnalyzer(DefaultRunner)
+	a.Flags.Init(a.Name, flag.ExitOnError)
+	DefaultRunner.RegisterFlags(&a.Flags)
+	return a
+}
+
 func NakedReturnAnalyzer(nakedRet *NakedReturnRunner) *analysis.Analyzer {
 	a := &analysis.Analyzer{
 		Name:     "nakedret",
@@ -35,9 +61,107 @@ func NakedReturnAnalyzer(nakedRet *NakedReturnRunner) *analysis.Analyzer {
 type NakedReturnRunner struct {
 	MaxLength     uint
 	SkipTestFiles bool
+	// GroupByFunction reports a single diagnostic per function, at its name,
+	// with the naked returns as related information and a fix for all of them.
+	GroupByFunction bool
+	// LineLiteralNames names function literals after the line they start on,
+	// e.g. "<func():42>", instead of their role in the enclosing function.
+	LineLiteralNames bool
+	// ReportShort also reports, with SeverityInfo, the naked returns of
+	// exported functions that are not longer than MaxLength.
+	ReportShort bool
+
+	// ExportedMaxLength, ExportedMethodMaxLength, UnexportedMaxLength and
+	// LiteralMaxLength, if not nil, replace MaxLength for respectively the
+	// exported functions, the exported methods of exported types, the other
+	// functions and methods, and the function literals.
+	ExportedMaxLength       *uint
+	ExportedMethodMaxLength *uint
+	UnexportedMaxLength     *uint
+	LiteralMaxLength        *uint
+	// SkipMain skips the files of main packages.
+	SkipMain bool
+
+	// DeferredResults sets how functions whose named results are assigned in
+	// a deferred function literal are reported, and DeferMaxLength is their
+	// maximum length with DeferThreshold.
+	DeferredResults DeferMode
+	DeferMaxLength  uint
+
+	// PerReturn compares the maximum length to the number of lines from the
+	// start of the function to each naked return instead of the length of
+	// the function, so that early guard clauses are not reported.
+	PerReturn bool
+
+	// MaxDepth, if not nil, is the maximum number of if, for, switch and
+	// select statements a naked return can be nested in within its function.
+	MaxDepth *uint
+
+	// PreferNakedUnder, if not nil, is the length up to which functions
+	// should return their named results with naked returns rather than
+	// listing them, in order, in explicit ones.
+	PreferNakedUnder *uint
+
+	// ReportUnusedResults reports the named results that are not referenced
+	// in the body of their function, except by naked returns.
+	ReportUnusedResults bool
+
+	// ReportShadowingResults reports the named results shadowing a
+	// predeclared identifier, an imported package or a package-level
+	// declaration. The identifiers are resolved with the type information of
+	// the pass if there is some.
+	ReportShadowingResults bool
+
+	// DisabledRules lists the IDs of the rules not to report, see Rules.
+	DisabledRules []string
+}
+
+// funcKind classifies functions by visibility, to apply them different
+// maximum lengths.
+type funcKind int
+
+const (
+	exportedFunc funcKind = iota
+	exportedMethod
+	unexportedFunc
+	funcLiteral
+	numFuncKinds
+)
+
+// maxLengths returns the maximum length of each kind of function.
+func (n *NakedReturnRunner) maxLengths() [numFuncKinds]uint {
+	var maxLengths [numFuncKinds]uint
+	for kind, override := range [numFuncKinds]*uint{
+		exportedFunc:   n.ExportedMaxLength,
+		exportedMethod: n.ExportedMethodMaxLength,
+		unexportedFunc: n.UnexportedMaxLength,
+		funcLiteral:    n.LiteralMaxLength,
+	} {
+		maxLengths[kind] = n.MaxLength
+		if override != nil {
+			maxLengths[kind] = *override
+		}
+	}
+	return maxLengths
 }
 
 func (n *NakedReturnRunner) run(pass *analysis.Pass) (any, error) {
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+	n.visit(pass, func(r nakedReturn) {
+		// Drivers such as go vet only print the message, which names the
+		// rule to use in ignore directives and -disable.
+		d := r.diagnostic
+		d.Message += " (" + d.Category + ")"
+		pass.Report(d)
+	}, nil)
+	return nil, nil
+}
+
+// visit calls report for every naked return to report in the files of pass,
+// and popped, if not nil, for every function once it has been visited.
+func (n *NakedReturnRunner) visit(pass *analysis.Pass, report func(nakedReturn), popped func(name string, fun funcInfo)) {
 	inspector := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 
 	nodeFilter := []ast.Node{ // filter needed nodes: visit only them
@@ -46,74 +170,178 @@ func (n *NakedReturnRunner) run(pass *analysis.Pass) (any, error) {
 		(*ast.ReturnStmt)(nil),
 	}
 	retVis := &returnsVisitor{
-		pass:          pass,
-		f:             pass.Fset,
-		maxLength:     n.MaxLength,
-		skipTestFiles: n.SkipTestFiles,
+		pass:            pass,
+		f:               pass.Fset,
+		maxLengths:      n.maxLengths(),
+		skipTestFiles:   n.SkipTestFiles,
+		skipMain:        n.SkipMain,
+		groupByFunction: n.GroupByFunction,
+		lineLiterals:    n.LineLiteralNames,
+		reportShort:     n.ReportShort,
+		deferMode:       n.DeferredResults,
+		deferMaxLength:  n.DeferMaxLength,
+		perReturn:       n.PerReturn,
+		maxDepth:        n.MaxDepth,
+		preferNaked:     n.PreferNakedUnder,
+		unusedResults:   n.ReportUnusedResults,
+		shadowing:       n.ReportShadowingResults,
+		disabledRules:   n.DisabledRules,
+		report:          report,
+		popped:          popped,
+		directives:      make(map[*ast.File]fileDirectives),
 	}
-	inspector.Nodes(nodeFilter, retVis.NodesVisit)
-	return nil, nil
+	for _, file := range pass.Files {
+		fd := parseDirectives(pass.Fset, file)
+		retVis.directives[file] = fd
+		if fd.maxLength != nil && filepath.Base(pass.Fset.File(file.Pos()).Name()) == docFile {
+			retVis.pkgMaxLength = fd.maxLength
+		}
+	}
+	for _, file := range pass.Files {
+		retVis.reportInvalidDirectives(file)
+	}
+	if retVis.shadowing && pass.TypesInfo == nil {
+		retVis.pkgDecls = packageDecls(pass.Files)
+	}
+	inspector.WithStack(nodeFilter, retVis.NodesVisit)
 }
 
 type returnsVisitor struct {
-	pass          *analysis.Pass
-	f             *token.FileSet
-	maxLength     uint
-	skipTestFiles bool
+	pass            *analysis.Pass
+	f               *token.FileSet
+	maxLengths      [numFuncKinds]uint
+	skipTestFiles   bool
+	skipMain        bool
+	groupByFunction bool
+	lineLiterals    bool
+	reportShort     bool
+	deferMode       DeferMode
+	deferMaxLength  uint
+	perReturn       bool
+	maxDepth        *uint
+	preferNaked     *uint
+	unusedResults   bool
+	shadowing       bool
+	disabledRules   []string
+	report          func(nakedReturn)
+	popped          func(name string, fun funcInfo)
 
 	// functions contains funcInfo for each nested function definition encountered while visiting the AST.
 	functions []funcInfo
+	// directives holds the directives of each file and pkgMaxLength the
+	// maximum length set for the package, if any.
+	directives   map[*ast.File]fileDirectives
+	pkgMaxLength *uint
+	// pkgDecls holds the package-level declarations when looked up without
+	// type information.
+	pkgDecls map[string]outerObject
+
+	// file is the file being visited, fileLiterals counts the function
+	// literals declared outside of any function in it, by name, and
+	// fileDirectives holds its directives.
+	file           *ast.File
+	fileLiterals   map[string]int
+	fileDirectives fileDirectives
 }
 
 type funcInfo struct {
 	// Details of the function we're currently dealing with
 	funcType    *ast.FuncType
 	funcName    string
+	funcPos     token.Pos
 	funcLength  int
+	maxLength   uint
 	reportNaked bool
-}
+	exported    bool
+	// directiveMaxLength is the maximum length set by a directive for the
+	// function, if any, which also applies to the literals nested in it.
+	directiveMaxLength *uint
+	// deferPos is the position of the deferred function literal assigning
+	// the named results, if any and the defer mode needs it, and exempt
+	// reports whether this exempts the function with DeferExempt.
+	deferPos token.Pos
+	exempt   bool
 
-func checkNakedReturns(args []string, maxLength *uint, skipTestFiles bool, setExitStatus bool) error {
+	namedResults bool
+	// nakedCount is the number of naked returns, reported or not.
+	nakedCount int
 
-	fset := token.NewFileSet()
+	// nakedReturns holds the naked returns to report when the function is
+	// popped, if they are grouped by function.
+	nakedReturns []*ast.ReturnStmt
+	// depths holds the nesting depth of each of nakedReturns deeper than the
+	// maximum depth, 0 for the others.
+	depths []int
+	// shadowed holds the name of the result shadowed at each of
+	// nakedReturns, if any.
+	shadowed []string
+	// rule is the rule of the most severe of nakedReturns.
+	rule string
 
-	files, err := parseInput(args, fset)
-	if err != nil {
-		return fmt.Errorf("could not parse input: %v", err)
+	// literals counts the function literals declared in the function, by name.
+	literals map[string]int
+}
+
+// nakedReturn is the diagnostic of a naked return along with details about
+// the function it returns from.
+type nakedReturn struct {
+	diagnostic analysis.Diagnostic
+	funcName   string
+	funcPos    token.Pos
+	funcLength int
+	maxLength  uint
+	severity   Severity
+	// depth is the nesting depth of the naked return if deeper than the
+	// maximum depth, or the deepest of the grouped ones.
+	depth int
+}
+
+func newNakedReturn(d analysis.Diagnostic, name string, fun funcInfo, depth int) nakedReturn {
+	rule, _ := lookupRule(d.Category)
+	return nakedReturn{
+		diagnostic: d,
+		funcName:   name,
+		funcPos:    fun.funcPos,
+		funcLength: fun.funcLength,
+		maxLength:  fun.maxLength,
+		severity:   rule.Severity,
+		depth:      depth,
 	}
+}
 
+func checkNakedReturns(args []string, maxLength *uint, skipTestFiles bool, r Reporter) error {
 	if maxLength == nil {
 		return errors.New("max length nil")
 	}
 
-	analyzer := NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: *maxLength, SkipTestFiles: skipTestFiles})
-	pass := &analysis.Pass{
-		Analyzer: analyzer,
-		Fset:     fset,
-		Files:    files,
-		Report: func(d analysis.Diagnostic) {
-			log.Printf("%s:%d: %s", fset.Position(d.Pos).Filename, fset.Position(d.Pos).Line, d.Message)
-		},
-		ResultOf: map[*analysis.Analyzer]any{},
+	_, err := Run(args, Options{NakedReturnRunner: NakedReturnRunner{MaxLength: *maxLength, SkipTestFiles: skipTestFiles}}, r)
+	return err
+}
+
+// parseInput parses the files named by args. fileMode reports whether they
+// were named as files or import paths rather than directories.
+func (opts Options) parseInput(args []string, fset *token.FileSet) (files []*ast.File, fileMode bool, err error) {
+	filenames, fileMode, err := opts.inputFiles(args)
+	if err != nil {
+		return nil, false, err
 	}
-	result, err := inspect.Analyzer.Run(pass)
+	srcs, err := opts.readFiles(filenames)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
-	pass.ResultOf[inspect.Analyzer] = result
-
-	_, err = analyzer.Run(pass)
+	files, err = opts.parseFiles(fset, filenames, srcs)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
-
-	return nil
+	return files, fileMode, nil
 }
 
-func parseInput(args []string, fset *token.FileSet) ([]*ast.File, error) {
+// inputFiles returns the names of the Go files named by args. fileMode
+// reports whether they were named as files or import paths rather than
+// directories.
+func (opts Options) inputFiles(args []string) (filenames []string, fileMode bool, err error) {
 	var directoryList []string
-	var fileMode bool
-	files := make([]*ast.File, 0)
+	var fileList []string
 
 	if len(args) == 0 {
 		directoryList = append(directoryList, pwd)
@@ -128,16 +356,12 @@ func parseInput(args []string, fset *token.FileSet) ([]*ast.File, error) {
 			} else if isDir(arg) {
 				directoryList = append(directoryList, arg)
 
-			} else if exists(arg) {
+			} else if _, ok := opts.overlayName(arg); ok || exists(arg) {
 				if strings.HasSuffix(arg, ".go") {
 					fileMode = true
-					f, err := parser.ParseFile(fset, arg, nil, 0)
-					if err != nil {
-						return nil, err
-					}
-					files = append(files, f)
+					fileList = append(fileList, arg)
 				} else {
-					return nil, fmt.Errorf("invalid file %v specified", arg)
+					return nil, false, fmt.Errorf("invalid file %v specified", arg)
 				}
 			} else {
 
@@ -146,7 +370,7 @@ func parseInput(args []string, fset *token.FileSet) ([]*ast.File, error) {
 				for _, importPath := range imPaths {
 					pkg, err := build.Import(importPath, ".", 0)
 					if err != nil {
-						return nil, err
+						return nil, false, err
 					}
 					var stringFiles []string
 					stringFiles = append(stringFiles, pkg.GoFiles...)
@@ -159,39 +383,128 @@ func parseInput(args []string, fset *token.FileSet) ([]*ast.File, error) {
 					}
 
 					fileMode = true
-					for _, stringFile := range stringFiles {
-						f, err := parser.ParseFile(fset, stringFile, nil, 0)
-						if err != nil {
-							return nil, err
-						}
-						files = append(files, f)
-					}
-
+					fileList = append(fileList, stringFiles...)
 				}
 			}
 		}
 	}
 
-	// if we're not in file mode, then we need to grab each and every package in each directory
-	// we can to grab all the files
-	if !fileMode {
-		for _, fpath := range directoryList {
-			pkgs, err := parser.ParseDir(fset, fpath, nil, 0)
-			if err != nil {
-				return nil, err
+	if fileMode {
+		for i, filename := range fileList {
+			if name, ok := opts.overlayName(filename); ok {
+				fileList[i] = name
 			}
+		}
+		return fileList, true, nil
+	}
 
-			for _, pkg := range pkgs {
-				for _, f := range pkg.Files {
-					files = append(files, f)
+	// if we're not in file mode, then we need to grab each and every package in each directory
+	// we can to grab all the files
+	for _, dir := range directoryList {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, false, err
+		}
+		listed := make(map[string]bool)
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+				filename := filepath.Join(dir, entry.Name())
+				if name, ok := opts.overlayName(filename); ok {
+					filename = name
 				}
+				listed[filename] = true
+				filenames = append(filenames, filename)
+			}
+		}
+		// Overlay files need not exist on disk yet.
+		for _, name := range slices.Sorted(maps.Keys(opts.Overlay)) {
+			if !listed[name] && strings.HasSuffix(name, ".go") && sameFile(filepath.Dir(name), dir) {
+				filenames = append(filenames, name)
 			}
 		}
 	}
+	return filenames, false, nil
+}
+
+// overlayName returns the name under which the overlay holds filename, which
+// may be spelled differently, e.g. as an absolute path.
+func (opts Options) overlayName(filename string) (string, bool) {
+	if _, ok := opts.Overlay[filename]; ok {
+		return filename, true
+	}
+	for name := range opts.Overlay {
+		if sameFile(name, filename) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// sameFile reports whether the paths a and b name the same file.
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// readFiles returns the contents of filenames, taken from the overlay if present.
+func (opts Options) readFiles(filenames []string) ([][]byte, error) {
+	srcs := make([][]byte, len(filenames))
+	err := forEach(len(filenames), opts.Jobs, func(i int) (err error) {
+		if b, ok := opts.Overlay[filenames[i]]; ok {
+			srcs[i] = b
+			return nil
+		}
+		srcs[i], err = os.ReadFile(filenames[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return srcs, nil
+}
 
+// parseFiles parses the sources of filenames concurrently.
+func (opts Options) parseFiles(fset *token.FileSet, filenames []string, srcs [][]byte) ([]*ast.File, error) {
+	files := make([]*ast.File, len(filenames))
+	err := forEach(len(filenames), opts.Jobs, func(i int) (err error) {
+		files[i], err = parser.ParseFile(fset, filenames[i], srcs[i], parser.ParseComments)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 	return files, nil
 }
 
+// forEach calls f for every index in [0, n), running at most jobs calls
+// concurrently, or one per CPU if jobs is not positive. It returns the error
+// of the lowest failing index.
+func forEach(n, jobs int, f func(i int) error) error {
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	errs := make([]error, n)
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			errs[i] = f(i)
+			<-sem
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func isDir(filename string) bool {
 	fi, err := os.Stat(filename)
 	return err == nil && fi.IsDir()
@@ -224,6 +537,133 @@ func nestedFuncName(functions []funcInfo) string {
 	return strings.Join(names, ".")
 }
 
+// funcDeclName returns the name of a function, qualified with its receiver
+// type for methods, e.g. "(*Server).Close" or "List[T].Len".
+func funcDeclName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := ast.Unparen(fn.Recv.List[0].Type)
+	if _, ok := recv.(*ast.StarExpr); ok {
+		return "(" + types.ExprString(recv) + ")." + fn.Name.Name
+	}
+	return types.ExprString(recv) + "." + fn.Name.Name
+}
+
+// funcDeclKind returns the kind of the function declared by fn. Exported
+// methods of unexported types are not part of the API of the package and are
+// considered unexported.
+func funcDeclKind(fn *ast.FuncDecl) funcKind {
+	if !fn.Name.IsExported() {
+		return unexportedFunc
+	}
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return exportedFunc
+	}
+	recv := fn.Recv.List[0].Type
+	for {
+		switch t := ast.Unparen(recv).(type) {
+		case *ast.StarExpr:
+			recv = t.X
+			continue
+		case *ast.IndexExpr:
+			recv = t.X
+			continue
+		case *ast.IndexListExpr:
+			recv = t.X
+			continue
+		case *ast.Ident:
+			if t.IsExported() {
+				return exportedMethod
+			}
+		}
+		return unexportedFunc
+	}
+}
+
+// literalName returns the name of the function literal at the top of stack,
+// unique within the enclosing function.
+func (v *returnsVisitor) literalName(stack []ast.Node) string {
+	lit := stack[len(stack)-1]
+	if v.lineLiterals {
+		return fmt.Sprintf("<func():%v>", v.f.Position(lit.Pos()).Line)
+	}
+
+	counts := &v.fileLiterals
+	if len(v.functions) > 0 {
+		counts = &v.functions[len(v.functions)-1].literals
+	}
+	if *counts == nil {
+		*counts = make(map[string]int)
+	}
+
+	name, ordinal := literalRole(stack)
+	(*counts)[name]++
+	if n := (*counts)[name]; ordinal || n > 1 {
+		name = fmt.Sprintf("%s#%d", name, n)
+	}
+	return name
+}
+
+// literalRole names the function literal at the top of stack after its
+// syntactic role: the variable or field it is assigned to, the argument of a
+// call it is passed as, or the defer or go statement calling it. Otherwise,
+// the name is "func". ordinal reports whether the name only makes sense with
+// the index of the literal among the ones of the same name.
+func literalRole(stack []ast.Node) (name string, ordinal bool) {
+	child := stack[len(stack)-1]
+	i := len(stack) - 2
+	for ; i > 0; i-- {
+		paren, ok := stack[i].(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		child = paren
+	}
+
+	switch parent := stack[i].(type) {
+	case *ast.AssignStmt:
+		if len(parent.Lhs) == len(parent.Rhs) {
+			for j, rhs := range parent.Rhs {
+				if rhs == child && !isBlank(parent.Lhs[j]) {
+					return types.ExprString(parent.Lhs[j]), false
+				}
+			}
+		}
+	case *ast.ValueSpec:
+		for j, value := range parent.Values {
+			if value == child && j < len(parent.Names) && !isBlank(parent.Names[j]) {
+				return parent.Names[j].Name, false
+			}
+		}
+	case *ast.KeyValueExpr:
+		if parent.Value == child {
+			return types.ExprString(parent.Key), false
+		}
+	case *ast.CallExpr:
+		if parent.Fun == child {
+			switch stack[i-1].(type) {
+			case *ast.DeferStmt:
+				return "defer", true
+			case *ast.GoStmt:
+				return "go", true
+			}
+			break
+		}
+		for j, arg := range parent.Args {
+			if arg == child {
+				return fmt.Sprintf("%s#arg%d", types.ExprString(parent.Fun), j+1), false
+			}
+		}
+	}
+	return "func", true
+}
+
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
 func nakedReturnFix(s *ast.ReturnStmt, funcType *ast.FuncType) *ast.ReturnStmt {
 	var nameExprs []ast.Expr
 	for _, result := range funcType.Results.List {
@@ -238,51 +678,103 @@ func nakedReturnFix(s *ast.ReturnStmt, funcType *ast.FuncType) *ast.ReturnStmt {
 	return &sFix
 }
 
-func (v *returnsVisitor) NodesVisit(node ast.Node, push bool) bool {
+func (v *returnsVisitor) NodesVisit(node ast.Node, push bool, stack []ast.Node) bool {
+	if file := stack[0].(*ast.File); file != v.file {
+		v.file, v.fileLiterals, v.fileDirectives = file, nil, v.directives[file]
+	}
 	var (
 		funcType *ast.FuncType
+		body     *ast.BlockStmt
 		funcName string
+		kind     funcKind
 	)
 	switch s := node.(type) {
 	case *ast.FuncDecl:
 		// We've found a function
 		funcType = s.Type
-		funcName = s.Name.Name
+		body = s.Body
+		funcName = funcDeclName(s)
+		kind = funcDeclKind(s)
 	case *ast.FuncLit:
 		// We've found a function literal
 		funcType = s.Type
-		file := v.f.File(s.Pos())
-		funcName = fmt.Sprintf("<func():%v>", file.Position(s.Pos()).Line)
+		body = s.Body
+		kind = funcLiteral
+		if push {
+			funcName = v.literalName(stack)
+		}
 	case *ast.ReturnStmt:
 		// We've found a possibly naked return statement
-		fun := v.functions[len(v.functions)-1]
-		funName := nestedFuncName(v.functions)
-		if fun.reportNaked && len(s.Results) == 0 && push {
-			sFix := nakedReturnFix(s, fun.funcType)
-			b := &bytes.Buffer{}
-			err := printer.Fprint(b, v.f, sFix)
-			if err != nil {
-				log.Printf("failed to format named return fix: %s", err)
+		fun := &v.functions[len(v.functions)-1]
+		if !push || !fun.namedResults {
+			break
+		}
+		if len(s.Results) > 0 {
+			v.checkExplicitReturn(s, fun, stack)
+			break
+		}
+		fun.nakedCount++
+
+		rule, depth, shadowed := v.nakedRule(s, fun, stack)
+		if rule == "" {
+			break
+		}
+		if v.groupByFunction {
+			fun.nakedReturns = append(fun.nakedReturns, s)
+			fun.depths = append(fun.depths, depth)
+			fun.shadowed = append(fun.shadowed, shadowed)
+			r, _ := lookupRule(rule)
+			if prev, ok := lookupRule(fun.rule); !ok || r.Severity > prev.Severity {
+				fun.rule = rule
 			}
-			v.pass.Report(analysis.Diagnostic{
-				Pos:     s.Pos(),
-				End:     s.End(),
-				Message: fmt.Sprintf("naked return in func `%s` with %d lines of code", funName, fun.funcLength),
-				SuggestedFixes: []analysis.SuggestedFix{{
-					Message: "explicit return statement",
-					TextEdits: []analysis.TextEdit{{
-						Pos:     s.Pos(),
-						End:     s.End(),
-						NewText: b.Bytes()}},
-				}},
-			})
+			break
+		}
+
+		funName := nestedFuncName(v.functions)
+		message := fmt.Sprintf("naked return in func `%s` with %d lines of code", funName, fun.funcLength)
+		if v.perReturn {
+			message = fmt.Sprintf("naked return at line %d of func `%s`", v.funcLine(fun.funcPos, s.Pos()), funName)
+		}
+		if depth > 0 {
+			message += fmt.Sprintf(" at nesting depth %d", depth)
+		}
+		if shadowed != "" {
+			message += fmt.Sprintf(" while result `%s` is shadowed", shadowed)
 		}
+		message += v.deferNote(*fun)
+		d := analysis.Diagnostic{
+			Pos:      s.Pos(),
+			End:      s.End(),
+			Category: rule,
+			URL:      RuleURL(rule),
+			Message:  message,
+		}
+		// An explicit return would return the shadowing variable.
+		if shadowed == "" {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message: "explicit return statement",
+				TextEdits: []analysis.TextEdit{{
+					Pos:     s.Pos(),
+					End:     s.End(),
+					NewText: v.fixText(s, fun.funcType)}},
+			}}
+		}
+		v.report(newNakedReturn(d, funName, *fun, depth))
 	}
 
 	if !push {
 		if funcType == nil {
 			return false
 		}
+		if len(v.functions[len(v.functions)-1].nakedReturns) > 0 {
+			v.reportFunction(node)
+		}
+		if v.unusedResults && v.functions[len(v.functions)-1].namedResults {
+			v.checkUnusedResults(body)
+		}
+		if v.popped != nil {
+			v.popped(nestedFuncName(v.functions), v.functions[len(v.functions)-1])
+		}
 		// Pop function info
 		v.functions = v.functions[:len(v.functions)-1]
 		return false
@@ -294,18 +786,490 @@ func (v *returnsVisitor) NodesVisit(node ast.Node, push bool) bool {
 		if v.skipTestFiles && strings.HasSuffix(file.Name(), "_test.go") {
 			return false
 		}
-		length := file.Position(node.End()).Line - file.Position(node.Pos()).Line
-		if length == 0 {
-			// consider functions that finish on the same line as they start as single line functions, not zero lines!
-			length = 1
+		if v.skipMain && stack[0].(*ast.File).Name.Name == "main" {
+			return false
+		}
+		length := v.funcLine(node.Pos(), node.End())
+		namedResults := hasNamedReturns(funcType)
+		var deferPos token.Pos
+		if namedResults && v.deferMode != DeferReport {
+			deferPos = deferredResults(funcType, body)
+		}
+		exempt := deferPos.IsValid() && v.deferMode == DeferExempt
+		directiveMaxLength := v.directiveMaxLength(node)
+		maxLength := v.maxLengths[kind]
+		if deferPos.IsValid() && v.deferMode == DeferThreshold {
+			maxLength = v.deferMaxLength
+		}
+		if directiveMaxLength != nil {
+			maxLength = *directiveMaxLength
 		}
 		v.functions = append(v.functions, funcInfo{
-			funcType:    funcType,
-			funcName:    funcName,
-			funcLength:  length,
-			reportNaked: uint(length) > v.maxLength && hasNamedReturns(funcType),
+			funcType:           funcType,
+			funcName:           funcName,
+			funcPos:            node.Pos(),
+			funcLength:         length,
+			maxLength:          maxLength,
+			reportNaked:        uint(length) > maxLength && namedResults && !exempt,
+			exported:           kind == exportedFunc || kind == exportedMethod,
+			directiveMaxLength: directiveMaxLength,
+			deferPos:           deferPos,
+			exempt:             exempt,
+			namedResults:       namedResults,
 		})
+		if v.shadowing && namedResults {
+			v.checkShadowingResults(stack[0].(*ast.File))
+		}
 	}
 
 	return true
 }
+
+// funcLine returns the number of lines from the start of a function at
+// funcPos to pos, in the same file.
+func (v *returnsVisitor) funcLine(funcPos, pos token.Pos) int {
+	file := v.f.File(funcPos)
+	line := file.Position(pos).Line - file.Position(funcPos).Line
+	if line == 0 {
+		// consider functions that finish on the same line as they start as single line functions, not zero lines!
+		line = 1
+	}
+	return line
+}
+
+// directiveMaxLength returns the maximum length set by directives for the
+// function node, if any: in its doc comment, in the doc comment of an
+// enclosing function, for its file or for its package, in that order.
+func (v *returnsVisitor) directiveMaxLength(node ast.Node) *uint {
+	if fn, ok := node.(*ast.FuncDecl); ok {
+		if n, ok := v.fileDirectives.funcMaxLengths[fn]; ok {
+			return &n
+		}
+	}
+	if len(v.functions) > 0 {
+		return v.functions[len(v.functions)-1].directiveMaxLength
+	}
+	if v.fileDirectives.maxLength != nil {
+		return v.fileDirectives.maxLength
+	}
+	return v.pkgMaxLength
+}
+
+// reportInvalidDirectives reports the invalid directives of file.
+func (v *returnsVisitor) reportInvalidDirectives(file *ast.File) {
+	if v.skipTestFiles && strings.HasSuffix(v.f.File(file.Pos()).Name(), "_test.go") ||
+		v.skipMain && file.Name.Name == "main" ||
+		slices.Contains(v.disabledRules, RuleInvalidDirective) {
+		return
+	}
+	fd := v.directives[file]
+	for _, d := range fd.all {
+		if d.err == nil || fd.ignores.ignored(v.f.Position(d.comment.Pos()).Line, RuleInvalidDirective) {
+			continue
+		}
+		v.report(newNakedReturn(analysis.Diagnostic{
+			Pos:      d.comment.Pos(),
+			End:      d.comment.End(),
+			Category: RuleInvalidDirective,
+			URL:      RuleURL(RuleInvalidDirective),
+			Message:  fmt.Sprintf("invalid directive `%s`: %v", d.comment.Text, d.err),
+		}, "", funcInfo{}, 0))
+	}
+}
+
+// fixText returns the explicit return statement replacing the naked return s.
+func (v *returnsVisitor) fixText(s *ast.ReturnStmt, funcType *ast.FuncType) []byte {
+	sFix := nakedReturnFix(s, funcType)
+	b := &bytes.Buffer{}
+	err := printer.Fprint(b, v.f, sFix)
+	if err != nil {
+		log.Printf("failed to format named return fix: %s", err)
+	}
+	return b.Bytes()
+}
+
+// reportFunction reports the naked returns collected for the function node,
+// which is on top of the stack, in a single diagnostic at its name.
+func (v *returnsVisitor) reportFunction(node ast.Node) {
+	fun := v.functions[len(v.functions)-1]
+	pos, end := node.Pos(), node.End()
+	switch s := node.(type) {
+	case *ast.FuncDecl:
+		pos, end = s.Name.Pos(), s.Name.End()
+	case *ast.FuncLit:
+		pos, end = s.Type.Pos(), s.Type.End()
+	}
+
+	returns := "naked return"
+	if n := len(fun.nakedReturns); n > 1 {
+		returns = fmt.Sprintf("%d naked returns", n)
+	}
+	var related []analysis.RelatedInformation
+	var edits []analysis.TextEdit
+	maxDepth := 0
+	var lines []string
+	for i, s := range fun.nakedReturns {
+		message := "naked return"
+		if v.perReturn {
+			line := v.funcLine(fun.funcPos, s.Pos())
+			lines = append(lines, strconv.Itoa(line))
+			message += fmt.Sprintf(" at line %d", line)
+		}
+		if depth := fun.depths[i]; depth > 0 {
+			message += fmt.Sprintf(" at nesting depth %d", depth)
+			maxDepth = max(maxDepth, depth)
+		}
+		if shadowed := fun.shadowed[i]; shadowed != "" {
+			message += fmt.Sprintf(" while result `%s` is shadowed", shadowed)
+		}
+		related = append(related, analysis.RelatedInformation{
+			Pos:     s.Pos(),
+			End:     s.End(),
+			Message: message,
+		})
+		if fun.shadowed[i] != "" {
+			continue
+		}
+		edits = append(edits, analysis.TextEdit{
+			Pos:     s.Pos(),
+			End:     s.End(),
+			NewText: v.fixText(s, fun.funcType),
+		})
+	}
+
+	funName := nestedFuncName(v.functions)
+	message := fmt.Sprintf("%s in func `%s` with %d lines of code", returns, funName, fun.funcLength)
+	if v.perReturn {
+		at := "at line " + lines[0]
+		if len(lines) > 1 {
+			at = "at lines " + strings.Join(lines[:len(lines)-1], ", ") + " and " + lines[len(lines)-1]
+		}
+		message = fmt.Sprintf("%s %s of func `%s`", returns, at, funName)
+	}
+	d := analysis.Diagnostic{
+		Pos:      pos,
+		End:      end,
+		Category: fun.rule,
+		URL:      RuleURL(fun.rule),
+		Message:  message + v.deferNote(fun),
+		Related:  related,
+	}
+	if len(edits) > 0 {
+		d.SuggestedFixes = []analysis.SuggestedFix{{
+			Message:   "explicit return statements",
+			TextEdits: edits,
+		}}
+	}
+	v.report(newNakedReturn(d, funName, fun, maxDepth))
+}
+
+// deferNote returns the note appended to the messages of the function with
+// DeferAnnotate, pointing at the deferred function literal assigning its
+// results.
+func (v *returnsVisitor) deferNote(fun funcInfo) string {
+	if v.deferMode != DeferAnnotate || !fun.deferPos.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("; results modified by defer at line %d", v.f.Position(fun.deferPos).Line)
+}
+
+// rule returns the ID of the most severe of the rules reporting node, usually
+// a return statement, according to applies, that is neither disabled nor
+// suppressed.
+func (v *returnsVisitor) rule(node ast.Node, applies map[string]bool) string {
+	line := v.f.Position(node.Pos()).Line
+	for _, id := range []string{RuleShadowedResult, RuleLongFunc, RuleDeepReturn, RuleShortExported, RulePreferNaked, RuleUnusedResult, RuleShadowingResult} {
+		if applies[id] && !slices.Contains(v.disabledRules, id) && !v.fileDirectives.ignores.ignored(line, id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// nakedRule returns the rule reporting s, a return of the function fun whose
+// stack it tops, as a naked return, if any, with its nesting depth if deeper
+// than MaxDepth and the name of the result shadowed at s, if any.
+func (v *returnsVisitor) nakedRule(s *ast.ReturnStmt, fun *funcInfo, stack []ast.Node) (rule string, depth int, shadowed string) {
+	long := fun.reportNaked
+	if v.perReturn {
+		long = uint(v.funcLine(fun.funcPos, s.Pos())) > fun.maxLength && !fun.exempt
+	}
+	if v.maxDepth != nil {
+		if d := nestingDepth(stack); uint(d) > *v.maxDepth {
+			depth = d
+		}
+	}
+	shadowed = shadowedResult(fun.funcType, stack)
+	rule = v.rule(s, map[string]bool{
+		RuleShadowedResult: shadowed != "",
+		RuleLongFunc:       long,
+		RuleDeepReturn:     depth > 0,
+		RuleShortExported:  !long && v.reportShort && fun.exported && !fun.exempt,
+	})
+	return rule, depth, shadowed
+}
+
+// checkExplicitReturn reports the explicit return s of the function fun,
+// whose stack it tops, if it could be naked: the function is not longer than
+// PreferNakedUnder, s returns the named results in order and no rule would
+// report it once naked, e.g. because a result is shadowed.
+func (v *returnsVisitor) checkExplicitReturn(s *ast.ReturnStmt, fun *funcInfo, stack []ast.Node) {
+	if v.preferNaked == nil || uint(fun.funcLength) > *v.preferNaked {
+		return
+	}
+	var names []string
+	for _, field := range fun.funcType.Results.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	if len(s.Results) != len(names) {
+		return
+	}
+	for i, result := range s.Results {
+		if ident, ok := result.(*ast.Ident); !ok || ident.Name != names[i] || ident.Name == "_" {
+			return
+		}
+	}
+	if rule, _, _ := v.nakedRule(s, fun, stack); rule != "" {
+		return
+	}
+	rule := v.rule(s, map[string]bool{RulePreferNaked: true})
+	if rule == "" {
+		return
+	}
+
+	funName := nestedFuncName(v.functions)
+	v.report(newNakedReturn(analysis.Diagnostic{
+		Pos:      s.Pos(),
+		End:      s.End(),
+		Category: rule,
+		URL:      RuleURL(rule),
+		Message:  fmt.Sprintf("explicit return of the named results in func `%s` with %d lines of code", funName, fun.funcLength),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message: "naked return statement",
+			TextEdits: []analysis.TextEdit{{
+				Pos:     s.Pos(),
+				End:     s.End(),
+				NewText: []byte("return")}},
+		}},
+	}, funName, *fun, 0))
+}
+
+// checkUnusedResults reports the named results of the function on top of the
+// stack that are not referenced in its body, except by naked returns. The fix
+// removes the names of the results if none is used and there is no naked
+// return, and otherwise renames the unused ones to _.
+func (v *returnsVisitor) checkUnusedResults(body *ast.BlockStmt) {
+	fun := v.functions[len(v.functions)-1]
+	if body == nil {
+		return
+	}
+	used := make(map[string]bool)
+	var walk func(n ast.Node) bool
+	walk = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// Fields and methods are not the results.
+			ast.Inspect(n.X, walk)
+			return false
+		case *ast.Ident:
+			used[n.Name] = true
+		}
+		return true
+	}
+	ast.Inspect(body, walk)
+
+	var unused, named []*ast.Ident
+	results := fun.funcType.Results
+	numResults := 0
+	for _, field := range results.List {
+		numResults += max(1, len(field.Names))
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				continue
+			}
+			named = append(named, name)
+			if !used[name.Name] {
+				unused = append(unused, name)
+			}
+		}
+	}
+	if len(unused) == 0 {
+		return
+	}
+	rule := v.rule(results, map[string]bool{RuleUnusedResult: true})
+	if rule == "" {
+		return
+	}
+
+	var quoted []string
+	for _, name := range unused {
+		quoted = append(quoted, "`"+name.Name+"`")
+	}
+	funName := nestedFuncName(v.functions)
+	message := fmt.Sprintf("named result %s of func `%s` is ", quoted[0], funName)
+	if len(unused) > 1 {
+		message = fmt.Sprintf("named results %s of func `%s` are ", strings.Join(quoted, ", "), funName)
+	}
+	if fun.nakedCount > 0 {
+		message += "only used by naked returns"
+	} else {
+		message += "never used"
+	}
+
+	// The edits only touch the names, to keep the formatting and comments
+	// of the results.
+	fix := analysis.SuggestedFix{Message: "rename the unused results to _"}
+	if len(unused) == len(named) && fun.nakedCount == 0 {
+		fix.Message = "remove the names of the results"
+		for _, field := range results.List {
+			if len(field.Names) == 0 {
+				continue
+			}
+			// Fields declaring several names get their type repeated.
+			typ := types.ExprString(field.Type)
+			fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+				Pos:     field.Names[0].Pos(),
+				End:     field.Type.Pos(),
+				NewText: []byte(strings.Repeat(typ+", ", len(field.Names)-1)),
+			})
+		}
+		if numResults == 1 {
+			fix.TextEdits = append(fix.TextEdits,
+				analysis.TextEdit{Pos: results.Opening, End: results.Opening + 1},
+				analysis.TextEdit{Pos: results.Closing, End: results.Closing + 1})
+		}
+	} else {
+		for _, name := range unused {
+			fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+				Pos:     name.Pos(),
+				End:     name.End(),
+				NewText: []byte("_"),
+			})
+		}
+	}
+	v.report(newNakedReturn(analysis.Diagnostic{
+		Pos:            results.Pos(),
+		End:            results.End(),
+		Category:       rule,
+		URL:            RuleURL(rule),
+		Message:        message,
+		SuggestedFixes: []analysis.SuggestedFix{fix},
+	}, funName, fun, 0))
+}
+
+// nestingDepth returns the number of if, for, switch and select statements
+// the return statement at the top of stack is nested in within its function.
+// An else if counts with its if.
+func nestingDepth(stack []ast.Node) int {
+	depth := 0
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch s := stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return depth
+		case *ast.IfStmt:
+			if parent, ok := stack[i-1].(*ast.IfStmt); ok && parent.Else == s {
+				continue
+			}
+			depth++
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			depth++
+		}
+	}
+	return depth
+}
+
+// shadowedResult returns the name of a result of the function with type
+// funcType that is shadowed at the return statement at the top of stack, or
+// the empty string.
+func shadowedResult(funcType *ast.FuncType, stack []ast.Node) string {
+	results := make(map[string]bool)
+	for _, field := range funcType.Results.List {
+		for _, name := range field.Names {
+			results[name.Name] = true
+		}
+	}
+
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return ""
+		case *ast.BlockStmt:
+			// The body of the function is the scope of its results.
+			if _, ok := stack[i-1].(*ast.FuncDecl); ok {
+				continue
+			}
+			if _, ok := stack[i-1].(*ast.FuncLit); ok {
+				continue
+			}
+		}
+		for _, ident := range declaredBefore(stack[i], stack[i+1]) {
+			if results[ident.Name] {
+				return ident.Name
+			}
+		}
+	}
+	return ""
+}
+
+// declaredBefore returns the identifiers declared in the scope of parent
+// that are visible in its child node.
+func declaredBefore(parent, child ast.Node) []*ast.Ident {
+	var (
+		stmts  []ast.Stmt
+		idents []*ast.Ident
+	)
+	switch p := parent.(type) {
+	case *ast.BlockStmt:
+		stmts = p.List
+	case *ast.CaseClause:
+		stmts = p.Body
+	case *ast.CommClause:
+		stmts = append([]ast.Stmt{p.Comm}, p.Body...)
+	case *ast.IfStmt:
+		stmts = []ast.Stmt{p.Init}
+	case *ast.SwitchStmt:
+		stmts = []ast.Stmt{p.Init}
+	case *ast.TypeSwitchStmt:
+		stmts = []ast.Stmt{p.Init, p.Assign}
+	case *ast.ForStmt:
+		stmts = []ast.Stmt{p.Init}
+	case *ast.RangeStmt:
+		if p.Tok == token.DEFINE && p.Body == child {
+			for _, expr := range []ast.Expr{p.Key, p.Value} {
+				if ident, ok := expr.(*ast.Ident); ok {
+					idents = append(idents, ident)
+				}
+			}
+		}
+	}
+
+	for _, stmt := range stmts {
+		// Only the statements preceding child declare names visible in it.
+		if stmt == nil || stmt.Pos() >= child.Pos() {
+			continue
+		}
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			if stmt.Tok != token.DEFINE {
+				continue
+			}
+			for _, lhs := range stmt.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					idents = append(idents, ident)
+				}
+			}
+		case *ast.DeclStmt:
+			for _, spec := range stmt.Decl.(*ast.GenDecl).Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					idents = append(idents, spec.Names...)
+				case *ast.TypeSpec:
+					idents = append(idents, spec.Name)
+				}
+			}
+		}
+	}
+	return idents
+}
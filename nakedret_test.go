@@ -1,10 +1,10 @@
 package nakedret
 
 import (
-	"bytes"
-	"log"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -34,31 +34,43 @@ var testcases = []struct {
 	}},
 	{"nested function literals", strings.Join([]string{
 		"testdata/src/x/nested.go:16: naked return in func `Bad` with 6 lines of code",
-		"testdata/src/x/nested.go:21: naked return in func `BadNested.<func():20>` with 2 lines of code",
-		"testdata/src/x/nested.go:28: naked return in func `MoreBad.<func():27>` with 2 lines of code",
-		"testdata/src/x/nested.go:32: naked return in func `MoreBad.<func():31>` with 2 lines of code",
-		"testdata/src/x/nested.go:36: naked return in func `MoreBad.<func():35>` with 2 lines of code",
-		"testdata/src/x/nested.go:40: naked return in func `MoreBad.<func():39>` with 2 lines of code",
-		"testdata/src/x/nested.go:47: naked return in func `LiteralFuncCallReturn.<func():46>` with 2 lines of code",
-		"testdata/src/x/nested.go:55: naked return in func `LiteralFuncCallReturn2.<func():53>.<func():54>` with 2 lines of code",
+		"testdata/src/x/nested.go:21: naked return in func `BadNested.func#1` with 2 lines of code",
+		"testdata/src/x/nested.go:28: naked return in func `MoreBad.func#1` with 2 lines of code",
+		"testdata/src/x/nested.go:32: naked return in func `MoreBad.func#2` with 2 lines of code",
+		"testdata/src/x/nested.go:36: naked return in func `MoreBad.defer#1` with 2 lines of code",
+		"testdata/src/x/nested.go:40: naked return in func `MoreBad.go#1` with 2 lines of code",
+		"testdata/src/x/nested.go:47: naked return in func `LiteralFuncCallReturn.func#1` with 2 lines of code",
+		"testdata/src/x/nested.go:55: naked return in func `LiteralFuncCallReturn2.func#1.func#1` with 2 lines of code",
 		"testdata/src/x/nested.go:63: naked return in func `ManyReturns` with 8 lines of code",
 		"testdata/src/x/nested.go:65: naked return in func `ManyReturns` with 8 lines of code",
 		"testdata/src/x/nested.go:67: naked return in func `ManyReturns` with 8 lines of code",
-		"testdata/src/x/nested.go:78: naked return in func `DeeplyNested.<func():71>.<func():72>.<func():73>.<func():76>` with 3 lines of code",
-		"testdata/src/x/nested.go:81: naked return in func `DeeplyNested.<func():71>.<func():72>.<func():73>` with 12 lines of code",
-		"testdata/src/x/nested.go:84: naked return in func `DeeplyNested.<func():71>.<func():72>.<func():73>` with 12 lines of code",
-		"testdata/src/x/nested.go:87: naked return in func `DeeplyNested.<func():71>` with 17 lines of code",
+		"testdata/src/x/nested.go:78: naked return in func `DeeplyNested.f.defer#1.func#1.func#1` with 3 lines of code",
+		"testdata/src/x/nested.go:81: naked return in func `DeeplyNested.f.defer#1.func#1` with 12 lines of code",
+		"testdata/src/x/nested.go:84: naked return in func `DeeplyNested.f.defer#1.func#1` with 12 lines of code",
+		"testdata/src/x/nested.go:87: naked return in func `DeeplyNested.f` with 17 lines of code",
 		"testdata/src/x/nested.go:89: naked return in func `DeeplyNested` with 20 lines of code",
-		"testdata/src/x/nested.go:95: naked return in func `<func():92>.<func():94>` with 2 lines of code",
-		"testdata/src/x/nested.go:98: naked return in func `<func():92>` with 7 lines of code",
+		"testdata/src/x/nested.go:95: naked return in func `ToplevelFuncLit.func#1` with 2 lines of code",
+		"testdata/src/x/nested.go:98: naked return in func `ToplevelFuncLit` with 7 lines of code",
 		"testdata/src/x/nested.go:101: naked return in func `SingleLine` with 1 lines of code",
-		"testdata/src/x/nested.go:103: naked return in func `<func():103>` with 1 lines of code",
-		"testdata/src/x/nested.go:106: naked return in func `SingleLineNested.<func():106>` with 1 lines of code",
+		"testdata/src/x/nested.go:103: naked return in func `SingleLit` with 1 lines of code",
+		"testdata/src/x/nested.go:106: naked return in func `SingleLineNested.func#1` with 1 lines of code",
 		""}, "\n"),
 		testParams{
 			filename:  "testdata/src/x/nested.go",
 			maxLength: 0,
 		}},
+	{"methods", strings.Join([]string{
+		"testdata/src/methods/methods.go:8: naked return in func `(*Server).Close` with 2 lines of code",
+		"testdata/src/methods/methods.go:12: naked return in func `Client.Close` with 2 lines of code",
+		"testdata/src/methods/methods.go:16: naked return in func `(*Client).Reset` with 2 lines of code",
+		"testdata/src/methods/methods.go:22: naked return in func `(*List[T]).Pop` with 2 lines of code",
+		"testdata/src/methods/methods.go:29: naked return in func `Map[K, V].Get.f` with 2 lines of code",
+		"testdata/src/methods/methods.go:32: naked return in func `Map[K, V].Get` with 6 lines of code",
+		""}, "\n"),
+		testParams{
+			filename:  "testdata/src/methods/methods.go",
+			maxLength: 0,
+		}},
 	{"failing on test files",
 		"testdata/src/x/example_test.go:11: naked return in func `SomeTestHelperFunction` with 3 lines of code\n",
 		testParams{
@@ -74,23 +86,66 @@ var testcases = []struct {
 		}},
 }
 
+// checkFindings returns the findings of Check over patterns with runner.
+func checkFindings(t *testing.T, runner NakedReturnRunner, patterns ...string) []Finding {
+	t.Helper()
+	findings, err := Check(patterns, Options{NakedReturnRunner: runner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return findings
+}
+
+// checkSource returns the findings of Check over src, as the file x.go, with
+// runner.
+func checkSource(t *testing.T, runner NakedReturnRunner, src string) []Finding {
+	t.Helper()
+	findings, err := Check([]string{"x.go"}, Options{
+		NakedReturnRunner: runner,
+		Overlay:           map[string][]byte{"x.go": []byte(src)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return findings
+}
+
+// capturingReporter records the findings reported to it.
+type capturingReporter struct {
+	started, finished bool
+	findings          []Finding
+}
+
+func (r *capturingReporter) Start() error {
+	r.started = true
+	return nil
+}
+
+func (r *capturingReporter) Report(f Finding) error {
+	r.findings = append(r.findings, f)
+	return nil
+}
+
+func (r *capturingReporter) Finish() error {
+	r.finished = true
+	return nil
+}
+
 func runNakedret(t *testing.T, filename string, maxLength uint, skipTestFiles bool, expected string) {
 	t.Helper()
-	defer func() {
-		// Reset logging
-		log.SetOutput(os.Stderr)
-		log.SetFlags(log.LstdFlags)
-	}()
-	var logBuf bytes.Buffer
-	log.SetOutput(&logBuf)
-	log.SetFlags(0)
-
-	if err := checkNakedReturns([]string{filename}, &maxLength, skipTestFiles, false); err != nil {
+	r := &capturingReporter{}
+	if err := checkNakedReturns([]string{filename}, &maxLength, skipTestFiles, r); err != nil {
 		t.Fatal(err)
 	}
-	actual := logBuf.String()
-	if expected != actual {
-		t.Errorf("Unexpected output:\n-----\ngot: \n%s\nexpected: \n%v\n-----\n", actual, expected)
+	if !r.started || !r.finished {
+		t.Errorf("reporter started %v, finished %v", r.started, r.finished)
+	}
+	var actual strings.Builder
+	for _, f := range r.findings {
+		fmt.Fprintf(&actual, "%s:%d: %s\n", f.Pos.Filename, f.Pos.Line, f.Message)
+	}
+	if expected != actual.String() {
+		t.Errorf("Unexpected output:\n-----\ngot: \n%s\nexpected: \n%v\n-----\n", actual.String(), expected)
 	}
 }
 
@@ -119,5 +174,168 @@ func TestAllFixes(t *testing.T) {
 	}
 
 	testdata := filepath.Join(wd, "testdata")
-	analysistest.RunWithSuggestedFixes(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{0, true}), "x")
+	analysistest.RunWithSuggestedFixes(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: 0, SkipTestFiles: true}), "x", "methods")
+}
+
+func TestPreferNaked(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	three := uint(3)
+	testdata := filepath.Join(wd, "testdata")
+	analysistest.RunWithSuggestedFixes(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: 100, PreferNakedUnder: &three}), "prefernaked")
+}
+
+func TestPreferNakedReported(t *testing.T) {
+	const src = `package x
+
+func F(b bool) (n int) {
+	if b {
+		return n
+	}
+	return n
+}
+`
+	five, zero := uint(5), uint(0)
+	for _, tt := range []struct {
+		name      string
+		runner    NakedReturnRunner
+		wantLines []int
+	}{
+		{"within limit", NakedReturnRunner{MaxLength: 10, PreferNakedUnder: &five}, []int{5, 7}},
+		{"over limit", NakedReturnRunner{MaxLength: 1, PreferNakedUnder: &five}, nil},
+		{"per return", NakedReturnRunner{MaxLength: 2, PreferNakedUnder: &five, PerReturn: true}, []int{5}},
+		{"deep", NakedReturnRunner{MaxLength: 10, PreferNakedUnder: &five, MaxDepth: &zero}, []int{7}},
+		{"short exported", NakedReturnRunner{MaxLength: 10, PreferNakedUnder: &five, ReportShort: true}, nil},
+		{"disabled", NakedReturnRunner{MaxLength: 1, PreferNakedUnder: &five, DisabledRules: []string{RuleLongFunc}}, []int{5, 7}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []int
+			for _, f := range checkSource(t, tt.runner, src) {
+				if f.Rule != RulePreferNaked {
+					t.Errorf("unexpected finding %+v", f)
+				}
+				lines = append(lines, f.Pos.Line)
+			}
+			if !slices.Equal(lines, tt.wantLines) {
+				t.Errorf("got NR006 at lines %v, want %v", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestUnusedResults(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(wd, "testdata")
+	analysistest.RunWithSuggestedFixes(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: 100, ReportUnusedResults: true}), "unused")
+}
+
+func TestShadowingResults(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(wd, "testdata")
+	analysistest.Run(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: 100, ReportShadowingResults: true}), "shadowing")
+}
+
+func TestGroupByFunction(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(wd, "testdata")
+	analysistest.RunWithSuggestedFixes(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{GroupByFunction: true}), "grouped")
+}
+
+const literalsSrc = `package x
+
+import "net/http"
+
+type server struct{ close func() error }
+
+func Handlers() {
+	handler := func() (n int) {
+		return
+	}
+	handler = func() (n int) {
+		return
+	}
+	http.HandleFunc("/", func(http.ResponseWriter, *http.Request) {})
+	_ = server{close: func() (err error) {
+		return
+	}}
+	_ = map[string]func() (err error){"x": (func() (err error) {
+		return
+	})}
+	forEach(func() (err error) {
+		return
+	})
+}
+
+func forEach(func() error) {}
+`
+
+func TestLiteralNames(t *testing.T) {
+	for _, tt := range []struct {
+		lineNames bool
+		expected  []string
+	}{
+		{false, []string{"Handlers.handler", "Handlers.handler#2", "Handlers.close", `Handlers."x"`, "Handlers.forEach#arg1"}},
+		{true, []string{"Handlers.<func():8>", "Handlers.<func():11>", "Handlers.<func():15>", "Handlers.<func():18>", "Handlers.<func():21>"}},
+	} {
+		findings := checkSource(t, NakedReturnRunner{LineLiteralNames: tt.lineNames}, literalsSrc)
+		var names []string
+		for _, f := range findings {
+			names = append(names, f.Func)
+		}
+		if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("line names %v: got %q, expected %q", tt.lineNames, names, tt.expected)
+		}
+	}
+}
+
+func TestPerReturn(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(wd, "testdata")
+	analysistest.Run(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: 5, PerReturn: true}), "perreturn")
+	analysistest.Run(t, testdata, NakedReturnAnalyzer(&NakedReturnRunner{MaxLength: 5, PerReturn: true, GroupByFunction: true}), "perreturngrouped")
+}
+
+func TestAnalyzerFlags(t *testing.T) {
+	saved := *DefaultRunner
+	defer func() { *DefaultRunner = saved }()
+
+	if DefaultRunner.MaxLength != DefaultMaxLength {
+		t.Errorf("got default max length %d, expected %d", DefaultRunner.MaxLength, DefaultMaxLength)
+	}
+	for name, value := range map[string]string{"l": "0", "skip-test-files": "true", "l-literals": "3"} {
+		if err := Analyzer.Flags.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if DefaultRunner.LiteralMaxLength == nil || *DefaultRunner.LiteralMaxLength != 3 {
+		t.Errorf("got literal max length %v, expected 3", DefaultRunner.LiteralMaxLength)
+	}
+	if err := Analyzer.Flags.Set("l-literals", "0"); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+	analysistest.Run(t, filepath.Join(wd, "testdata"), Analyzer, "x")
 }
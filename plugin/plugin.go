@@ -0,0 +1,128 @@
+// Package plugin exposes nakedret as a golangci-lint module plugin.
+//
+// It is registered under the name "nakedret" and accepts the following settings:
+//
+//	linters-settings:
+//	  custom:
+//	    nakedret:
+//	      type: module
+//	      settings:
+//	        max-func-lines: 5
+//	        max-func-lines-exported: 0
+//	        max-func-lines-exported-methods: 0
+//	        max-func-lines-unexported: 10
+//	        max-func-lines-literals: 5
+//	        skip-test-files: false
+//	        group-by-function: false
+//	        line-literal-names: false
+//	        report-short: false
+//	        skip-main: false
+//	        deferred-results: threshold
+//	        max-func-lines-deferred: 20
+//	        per-return: false
+//	        max-depth: 3
+//	        prefer-naked-under: 3
+//	        unused-results: false
+//	        shadowing-results: false
+//	        disable: [NR003]
+//
+// The max-func-lines-* settings default to max-func-lines, except
+// max-func-lines-deferred which only applies with deferred-results set to
+// threshold. deferred-results is report, annotate, exempt or threshold.
+package plugin
+
+import (
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+const defaultMaxFuncLines = nakedret.DefaultMaxLength
+
+func init() {
+	register.Plugin("nakedret", New)
+}
+
+// Settings holds the plugin configuration as written in .golangci.yml.
+type Settings struct {
+	MaxFuncLines                *uint `json:"max-func-lines"`
+	MaxFuncLinesExported        *uint `json:"max-func-lines-exported"`
+	MaxFuncLinesExportedMethods *uint `json:"max-func-lines-exported-methods"`
+	MaxFuncLinesUnexported      *uint `json:"max-func-lines-unexported"`
+	MaxFuncLinesLiterals        *uint `json:"max-func-lines-literals"`
+	SkipTestFiles               bool  `json:"skip-test-files"`
+	SkipMain                    bool  `json:"skip-main"`
+	GroupByFunction             bool  `json:"group-by-function"`
+	LineLiteralNames            bool  `json:"line-literal-names"`
+	ReportShort                 bool  `json:"report-short"`
+	// DeferredResults sets how functions whose named results are assigned in
+	// a deferred function literal are reported.
+	DeferredResults      nakedret.DeferMode `json:"deferred-results"`
+	MaxFuncLinesDeferred uint               `json:"max-func-lines-deferred"`
+	PerReturn            bool               `json:"per-return"`
+	MaxDepth             *uint              `json:"max-depth"`
+	PreferNakedUnder     *uint              `json:"prefer-naked-under"`
+	UnusedResults        bool               `json:"unused-results"`
+	ShadowingResults     bool               `json:"shadowing-results"`
+	// Disable lists the IDs of the rules not to report.
+	Disable []string `json:"disable"`
+}
+
+// runner converts the settings into the configuration of the analyzer.
+func (s Settings) runner() *nakedret.NakedReturnRunner {
+	maxLength := uint(defaultMaxFuncLines)
+	if s.MaxFuncLines != nil {
+		maxLength = *s.MaxFuncLines
+	}
+	return &nakedret.NakedReturnRunner{
+		MaxLength:               maxLength,
+		ExportedMaxLength:       s.MaxFuncLinesExported,
+		ExportedMethodMaxLength: s.MaxFuncLinesExportedMethods,
+		UnexportedMaxLength:     s.MaxFuncLinesUnexported,
+		LiteralMaxLength:        s.MaxFuncLinesLiterals,
+		SkipTestFiles:           s.SkipTestFiles,
+		SkipMain:                s.SkipMain,
+		GroupByFunction:         s.GroupByFunction,
+		LineLiteralNames:        s.LineLiteralNames,
+		ReportShort:             s.ReportShort,
+		DeferredResults:         s.DeferredResults,
+		DeferMaxLength:          s.MaxFuncLinesDeferred,
+		PerReturn:               s.PerReturn,
+		MaxDepth:                s.MaxDepth,
+		PreferNakedUnder:        s.PreferNakedUnder,
+		ReportUnusedResults:     s.UnusedResults,
+		ReportShadowingResults:  s.ShadowingResults,
+		DisabledRules:           s.Disable,
+	}
+}
+
+// Plugin implements register.LinterPlugin.
+type Plugin struct {
+	settings Settings
+}
+
+// New decodes the golangci-lint settings and returns the plugin.
+func New(settings any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.runner().Validate(); err != nil {
+		return nil, err
+	}
+	return &Plugin{settings: s}, nil
+}
+
+func (p *Plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return []*analysis.Analyzer{nakedret.NakedReturnAnalyzer(p.settings.runner())}, nil
+}
+
+// GetLoadMode requests type information only for shadowing-results, which
+// resolves identifiers with it.
+func (p *Plugin) GetLoadMode() string {
+	if p.settings.ShadowingResults {
+		return register.LoadModeTypesInfo
+	}
+	return register.LoadModeSyntax
+}
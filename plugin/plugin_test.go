@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/alexkohler/nakedret/v2"
+)
+
+func TestSettings(t *testing.T) {
+	ptr := func(n uint) *uint { return &n }
+	testcases := []struct {
+		name     string
+		settings any
+		// want is the configuration of the analyzer, nil if the settings are
+		// invalid.
+		want     *nakedret.NakedReturnRunner
+		loadMode string
+	}{
+		{"defaults", nil, &nakedret.NakedReturnRunner{MaxLength: defaultMaxFuncLines}, register.LoadModeSyntax},
+		{"empty", map[string]any{}, &nakedret.NakedReturnRunner{MaxLength: defaultMaxFuncLines}, register.LoadModeSyntax},
+		{"max length", map[string]any{"max-func-lines": 0}, &nakedret.NakedReturnRunner{MaxLength: 0}, register.LoadModeSyntax},
+		{
+			"visibility",
+			map[string]any{"max-func-lines": 30, "max-func-lines-exported": 0, "max-func-lines-exported-methods": 1, "max-func-lines-unexported": 10, "max-func-lines-literals": 50},
+			&nakedret.NakedReturnRunner{MaxLength: 30, ExportedMaxLength: ptr(0), ExportedMethodMaxLength: ptr(1), UnexportedMaxLength: ptr(10), LiteralMaxLength: ptr(50)},
+			register.LoadModeSyntax,
+		},
+		{
+			"switches",
+			map[string]any{"skip-test-files": true, "skip-main": true, "group-by-function": true, "line-literal-names": true, "report-short": true, "per-return": true, "unused-results": true},
+			&nakedret.NakedReturnRunner{MaxLength: defaultMaxFuncLines, SkipTestFiles: true, SkipMain: true, GroupByFunction: true, LineLiteralNames: true, ReportShort: true, PerReturn: true, ReportUnusedResults: true},
+			register.LoadModeSyntax,
+		},
+		{
+			"deferred results",
+			map[string]any{"deferred-results": "threshold", "max-func-lines-deferred": 20},
+			&nakedret.NakedReturnRunner{MaxLength: defaultMaxFuncLines, DeferredResults: nakedret.DeferThreshold, DeferMaxLength: 20},
+			register.LoadModeSyntax,
+		},
+		{
+			"limits",
+			map[string]any{"max-depth": 3, "prefer-naked-under": 2},
+			&nakedret.NakedReturnRunner{MaxLength: defaultMaxFuncLines, MaxDepth: ptr(3), PreferNakedUnder: ptr(2)},
+			register.LoadModeSyntax,
+		},
+		{
+			"disable",
+			map[string]any{"disable": []any{"NR003", "NR005"}},
+			&nakedret.NakedReturnRunner{MaxLength: defaultMaxFuncLines, DisabledRules: []string{"NR003", "NR005"}},
+			register.LoadModeSyntax,
+		},
+		{
+			"shadowing results",
+			map[string]any{"shadowing-results": true},
+			&nakedret.NakedReturnRunner{MaxLength: defaultMaxFuncLines, ReportShadowingResults: true},
+			register.LoadModeTypesInfo,
+		},
+		{"unknown setting", map[string]any{"max-length": 30}, nil, ""},
+		{"wrong type", map[string]any{"max-func-lines": "thirty"}, nil, ""},
+		{"negative length", map[string]any{"max-func-lines": -1}, nil, ""},
+		{"unknown rule", map[string]any{"disable": []any{"NR999"}}, nil, ""},
+		{"unknown defer mode", map[string]any{"deferred-results": "ignore"}, nil, ""},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.settings)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if runner := p.(*Plugin).settings.runner(); !reflect.DeepEqual(runner, tt.want) {
+				t.Errorf("got runner %+v, expected %+v", *runner, *tt.want)
+			}
+			if p.GetLoadMode() != tt.loadMode {
+				t.Errorf("got load mode %q, expected %q", p.GetLoadMode(), tt.loadMode)
+			}
+		})
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	newPlugin, err := register.GetPlugin("nakedret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newPlugin(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := p.GetLoadMode(); mode != register.LoadModeSyntax {
+		t.Errorf("unexpected load mode %q", mode)
+	}
+}
+
+func TestBuildAnalyzers(t *testing.T) {
+	p, err := New(map[string]any{"max-func-lines": 0, "skip-test-files": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analyzers) != 1 || analyzers[0].Name != "nakedret" {
+		t.Fatalf("unexpected analyzers %v", analyzers)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(wd, "..", "testdata")
+	analysistest.Run(t, testdata, analyzers[0], "x")
+}
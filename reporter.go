@@ -0,0 +1,124 @@
+package nakedret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Reporter receives the findings of Run.
+type Reporter interface {
+	// Start is called once the input has been checked, before the findings
+	// are reported.
+	Start() error
+	// Report is called for each finding, in the order of Check.
+	Report(f Finding) error
+	// Finish is called after the last finding.
+	Finish() error
+}
+
+// reporters maps the names of the formats known to NewReporter to the
+// constructors of their reporters.
+var reporters = map[string]func(w io.Writer) Reporter{
+	"text": NewTextReporter,
+	"json": NewJSONReporter,
+}
+
+// RegisterReporter makes the reporters built by newReporter available to
+// NewReporter under name, replacing any previous registration. It is meant to
+// be called from init functions and is not safe for concurrent use.
+func RegisterReporter(name string, newReporter func(w io.Writer) Reporter) {
+	reporters[name] = newReporter
+}
+
+// NewReporter returns a reporter writing findings to w in the named format,
+// either "text", "json" or a format added with RegisterReporter.
+func NewReporter(name string, w io.Writer) (Reporter, error) {
+	newReporter, ok := reporters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+	return newReporter(w), nil
+}
+
+// ReporterNames returns the sorted names of the formats known to NewReporter.
+func ReporterNames() []string {
+	return slices.Sorted(maps.Keys(reporters))
+}
+
+// Run checks the files, directories or packages named by patterns like Check
+// and reports the findings to r. It returns the findings as well, e.g. to
+// compute an exit status.
+func Run(patterns []string, opts Options, r Reporter) ([]Finding, error) {
+	findings, err := Check(patterns, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Start(); err != nil {
+		return nil, err
+	}
+	for _, f := range findings {
+		if err := r.Report(f); err != nil {
+			return nil, err
+		}
+	}
+	return findings, r.Finish()
+}
+
+// NewTextReporter returns a reporter writing each finding on its own line,
+// as "file:line:column: message (rule)".
+func NewTextReporter(w io.Writer) Reporter {
+	return textReporter{w}
+}
+
+type textReporter struct {
+	w io.Writer
+}
+
+func (textReporter) Start() error  { return nil }
+func (textReporter) Finish() error { return nil }
+
+func (r textReporter) Report(f Finding) error {
+	_, err := fmt.Fprintf(r.w, "%s: %s (%s)\n", f.Pos, f.Message, f.Rule)
+	return err
+}
+
+// NewJSONReporter returns a reporter writing the findings as a JSON array.
+func NewJSONReporter(w io.Writer) Reporter {
+	return &jsonReporter{w: w}
+}
+
+type jsonReporter struct {
+	w       io.Writer
+	written bool
+}
+
+func (r *jsonReporter) Start() error {
+	_, err := io.WriteString(r.w, "[")
+	return err
+}
+
+func (r *jsonReporter) Report(f Finding) error {
+	b, err := json.MarshalIndent(f, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if !r.written {
+		sep = "\n  "
+	}
+	r.written = true
+	_, err = fmt.Fprintf(r.w, "%s%s", sep, b)
+	return err
+}
+
+func (r *jsonReporter) Finish() error {
+	end := "]\n"
+	if r.written {
+		end = "\n]\n"
+	}
+	_, err := io.WriteString(r.w, end)
+	return err
+}
@@ -0,0 +1,107 @@
+package nakedret
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTextReporter(t *testing.T) {
+	var b bytes.Buffer
+	if _, err := Run([]string{"testdata/src/x/ret-in-block.go"}, Options{}, NewTextReporter(&b)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "testdata/src/x/ret-in-block.go:9:3: naked return in func `Dummy` with 8 lines of code (NR001)\n"
+	if b.String() != expected {
+		t.Errorf("got %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestJSONReporter(t *testing.T) {
+	for _, filename := range []string{"testdata/src/x/ret-in-block.go", "testdata/src/x/nested.go", "testdata/src/grouped/grouped.go"} {
+		var b bytes.Buffer
+		r, err := NewReporter("json", &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		findings, err := Run([]string{filename}, Options{NakedReturnRunner: NakedReturnRunner{MaxLength: 5}}, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded []Finding
+		if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+			t.Fatalf("%s: invalid JSON %q: %v", filename, b.String(), err)
+		}
+		if len(decoded) != len(findings) {
+			t.Fatalf("%s: decoded %d findings, expected %d", filename, len(decoded), len(findings))
+		}
+		var raw []struct {
+			Pos map[string]any `json:"pos"`
+		}
+		if err := json.Unmarshal(b.Bytes(), &raw); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range raw {
+			if keys := slices.Sorted(maps.Keys(f.Pos)); !slices.Equal(keys, []string{"column", "filename", "line", "offset"}) {
+				t.Errorf("%s: got position fields %v", filename, keys)
+			}
+		}
+		for i := range findings {
+			if decoded[i].Message != findings[i].Message || decoded[i].Severity != findings[i].Severity || decoded[i].Rule != findings[i].Rule {
+				t.Errorf("%s: decoded %+v, expected %+v", filename, decoded[i], findings[i])
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	r := NewJSONReporter(&b)
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "[]\n" {
+		t.Errorf("got %q without findings", b.String())
+	}
+}
+
+type countingReporter struct {
+	w io.Writer
+	n int
+}
+
+func (r *countingReporter) Start() error         { return nil }
+func (r *countingReporter) Report(Finding) error { r.n++; return nil }
+func (r *countingReporter) Finish() error {
+	_, err := io.WriteString(r.w, strings.Repeat("*", r.n))
+	return err
+}
+
+func TestRegisterReporter(t *testing.T) {
+	RegisterReporter("count", func(w io.Writer) Reporter { return &countingReporter{w: w} })
+	defer delete(reporters, "count")
+
+	if names := ReporterNames(); !slices.Equal(names, []string{"count", "json", "text"}) {
+		t.Errorf("got reporters %v", names)
+	}
+	var b bytes.Buffer
+	r, err := NewReporter("count", &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run([]string{"testdata/src/x/ret-in-block.go"}, Options{}, r); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "*" {
+		t.Errorf("got %q", b.String())
+	}
+	if _, err := NewReporter("xml", &b); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,94 @@
+package nakedret
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IDs of the rules checked by nakedret. They are the Category of the
+// diagnostics and can be used to disable rules or suppress their findings.
+const (
+	// RuleLongFunc reports naked returns in functions longer than the limit.
+	RuleLongFunc = "NR001"
+	// RuleShadowedResult reports naked returns while a result is shadowed.
+	RuleShadowedResult = "NR002"
+	// RuleShortExported reports naked returns in short exported functions,
+	// with NakedReturnRunner.ReportShort.
+	RuleShortExported = "NR003"
+	// RuleInvalidDirective reports //nakedret: comments that are ignored
+	// because they are malformed or misplaced.
+	RuleInvalidDirective = "NR004"
+	// RuleDeepReturn reports naked returns nested in more statements than
+	// NakedReturnRunner.MaxDepth.
+	RuleDeepReturn = "NR005"
+	// RulePreferNaked reports explicit returns of the named results in
+	// functions not longer than NakedReturnRunner.PreferNakedUnder.
+	RulePreferNaked = "NR006"
+	// RuleUnusedResult reports named results only used by naked returns, if
+	// at all, with NakedReturnRunner.ReportUnusedResults.
+	RuleUnusedResult = "NR007"
+	// RuleShadowingResult reports named results shadowing a predeclared,
+	// imported or package-level identifier, with
+	// NakedReturnRunner.ReportShadowingResults.
+	RuleShadowingResult = "NR008"
+)
+
+// Rule describes a rule checked by nakedret.
+type Rule struct {
+	ID       string
+	Summary  string
+	Severity Severity
+}
+
+// Rules lists the rules checked by nakedret.
+var Rules = []Rule{
+	{RuleLongFunc, "naked return in a function longer than the limit", SeverityWarning},
+	{RuleShadowedResult, "naked return while a named result is shadowed", SeverityError},
+	{RuleShortExported, "naked return in a short exported function", SeverityInfo},
+	{RuleInvalidDirective, "invalid //nakedret: directive", SeverityError},
+	{RuleDeepReturn, "naked return nested deeper than the limit", SeverityWarning},
+	{RulePreferNaked, "explicit return of the named results in a tiny function", SeverityInfo},
+	{RuleUnusedResult, "named result only used by naked returns, if at all", SeverityInfo},
+	{RuleShadowingResult, "named result shadowing a predeclared, imported or package-level identifier", SeverityWarning},
+}
+
+// ruleDocURL is the documentation of the rules, each under a heading named
+// after its ID.
+const ruleDocURL = "https://github.com/alexkohler/nakedret#"
+
+// RuleURL returns the URL of the documentation of the rule with the given ID.
+func RuleURL(id string) string {
+	return ruleDocURL + strings.ToLower(id)
+}
+
+func lookupRule(id string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// parseRules parses a comma separated list of rule IDs.
+func parseRules(list string) ([]string, error) {
+	var ids []string
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		if _, ok := lookupRule(id); !ok {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Validate checks that the configuration only names known rules.
+func (n *NakedReturnRunner) Validate() error {
+	for _, id := range n.DisabledRules {
+		if _, ok := lookupRule(id); !ok {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+	}
+	return nil
+}
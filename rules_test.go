@@ -0,0 +1,189 @@
+package nakedret
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const ignoreSrc = `package x
+
+func Ignored() (err error) {
+	_ = 0
+	return //nakedret:ignore
+}
+
+func IgnoredAbove() (err error) {
+	_ = 0
+	//nakedret:ignore NR001 legacy code
+	return
+}
+
+func OtherRule() (err error) {
+	_ = 0
+	//nakedret:ignore NR003
+	return
+}
+
+func Typo() (err error) {
+	_ = 0
+	return //nakedret:ignore NR01
+}
+
+func Fallback() (err error) {
+	if err := do(); err != nil {
+		return //nakedret:ignore NR002
+	}
+	return
+}
+
+func do() error { return nil }
+`
+
+func ruleFindings(t *testing.T, runner NakedReturnRunner, src string) []string {
+	t.Helper()
+	findings := checkSource(t, runner, src)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Rule+" "+f.Func)
+	}
+	return got
+}
+
+func TestIgnoreComments(t *testing.T) {
+	got := ruleFindings(t, NakedReturnRunner{MaxLength: 1}, ignoreSrc)
+	expected := []string{"NR001 OtherRule", "NR001 Typo", "NR004 ", "NR001 Fallback", "NR001 Fallback"}
+	if strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestDisabledRules(t *testing.T) {
+	got := ruleFindings(t, NakedReturnRunner{MaxLength: 10, ReportShort: true, DisabledRules: []string{RuleShortExported}}, severitySrc)
+	expected := []string{"NR002 Shadowed", "NR002 Shadowed"}
+	if strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	_, err := Check([]string{"x.go"}, Options{
+		NakedReturnRunner: NakedReturnRunner{DisabledRules: []string{"NR999"}},
+		Overlay:           map[string][]byte{"x.go": []byte(severitySrc)},
+	})
+	if err == nil || !strings.Contains(err.Error(), `unknown rule "NR999"`) {
+		t.Errorf("expected an unknown rule error, got %v", err)
+	}
+}
+
+func TestRules(t *testing.T) {
+	for _, r := range Rules {
+		if got, ok := lookupRule(r.ID); !ok || got != r {
+			t.Errorf("lookup of %s: got %+v", r.ID, got)
+		}
+	}
+	if url := RuleURL(RuleLongFunc); url != "https://github.com/alexkohler/nakedret#nr001" {
+		t.Errorf("got URL %s", url)
+	}
+	if _, err := parseRules("NR001, NR002"); err != nil {
+		t.Error(err)
+	}
+}
+
+const depthSrc = `package x
+
+func Deep(xs [][]int) (n int) {
+	for _, row := range xs {
+		switch len(row) {
+		case 0:
+			if n > 0 {
+				return
+			} else if n < 0 {
+				return
+			}
+		}
+	}
+	f := func() (m int) {
+		if m == 0 {
+			return
+		}
+		return
+	}
+	_ = f
+	return
+}
+`
+
+func TestNestingDepth(t *testing.T) {
+	two := uint(2)
+	findings := checkSource(t, NakedReturnRunner{MaxLength: 100, MaxDepth: &two}, depthSrc)
+	var got []string
+	for _, f := range findings {
+		got = append(got, fmt.Sprintf("%s %d %s", f.Rule, f.Depth, f.Message))
+	}
+	expected := []string{
+		"NR005 3 naked return in func `Deep` with 19 lines of code at nesting depth 3",
+		"NR005 3 naked return in func `Deep` with 19 lines of code at nesting depth 3",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	findings = checkSource(t, NakedReturnRunner{MaxLength: 100, MaxDepth: &two, GroupByFunction: true}, depthSrc)
+	if len(findings) != 1 || findings[0].Depth != 3 || len(findings[0].Related) != 2 ||
+		findings[0].Related[0].Message != "naked return at nesting depth 3" {
+		t.Errorf("got grouped findings %+v", findings)
+	}
+}
+
+func TestUnusedResultsFix(t *testing.T) {
+	src := `package x
+
+func Partly(m map[string]int) (n, total int, err error) {
+	total = len(m)
+	return 0, total, err
+}
+`
+	findings := checkSource(t, NakedReturnRunner{MaxLength: 100, ReportUnusedResults: true}, src)
+	if len(findings) != 1 || len(findings[0].Edits) != 1 {
+		t.Fatalf("got findings %+v", findings)
+	}
+	// Only the name is renamed.
+	if e := findings[0].Edits[0]; e.NewText != "_" || e.Pos.Line != 3 || e.Pos.Column != 32 || e.End.Column != 33 {
+		t.Errorf("got edit %+v", e)
+	}
+}
+
+func TestShadowingResultsWithoutTypes(t *testing.T) {
+	src := `package x
+
+import (
+	"errors"
+	yaml "gopkg.in/yaml.v3"
+	"example.com/mod/v2"
+)
+
+const limit = 3
+
+func Check() (len int, errors []error, limit, mod, yaml bool) {
+	return
+}
+`
+	findings := checkSource(t, NakedReturnRunner{MaxLength: 100, ReportShadowingResults: true}, src)
+	var got []string
+	for _, f := range findings {
+		s := f.Rule + " " + f.Message
+		for _, r := range f.Related {
+			s += fmt.Sprintf(" (%s at line %d)", r.Message, r.Pos.Line)
+		}
+		got = append(got, s)
+	}
+	expected := []string{
+		"NR008 named result `len` of func `Check` shadows the builtin `len`",
+		"NR008 named result `errors` of func `Check` shadows the imported package `errors` (imported package `errors` at line 4)",
+		"NR008 named result `limit` of func `Check` shadows the package-level const `limit` (package-level const `limit` at line 9)",
+		"NR008 named result `mod` of func `Check` shadows the imported package `mod` (imported package `mod` at line 6)",
+		"NR008 named result `yaml` of func `Check` shadows the imported package `yaml` (imported package `yaml` at line 5)",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
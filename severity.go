@@ -0,0 +1,59 @@
+package nakedret
+
+import "fmt"
+
+// Severity classifies findings by how likely they are to hide a bug.
+type Severity int
+
+const (
+	// SeverityInfo is a naked return in a short exported function, only
+	// reported with NakedReturnRunner.ReportShort.
+	SeverityInfo Severity = iota
+	// SeverityWarning is a naked return in a function longer than the limit.
+	SeverityWarning
+	// SeverityError is a naked return while a named result is shadowed, which
+	// the compiler rejects and whose explicit replacement would return the
+	// shadowing variable.
+	SeverityError
+)
+
+var severityNames = [...]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity returns the severity named s, as returned by String.
+func ParseSeverity(s string) (Severity, error) {
+	for i, name := range severityNames {
+		if name == s {
+			return Severity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q", s)
+}
+
+// Set implements flag.Value.
+func (s *Severity) Set(name string) error {
+	severity, err := ParseSeverity(name)
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
@@ -0,0 +1,92 @@
+package nakedret
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const severitySrc = `package x
+
+func Long() (err error) {
+	err = nil
+	err = nil
+	return
+}
+
+func Short() (err error) { return }
+
+func short() (err error) { return }
+
+func Shadowed() (n int, err error) {
+	if err := do(); err != nil {
+		return
+	}
+	for n := range 3 {
+		return
+	}
+	return
+}
+
+func do() error { return nil }
+`
+
+func TestSeverity(t *testing.T) {
+	findings := checkSource(t, NakedReturnRunner{MaxLength: 3, ReportShort: true}, severitySrc)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Severity.String()+" "+f.Message)
+		// An explicit return would return the shadowing variable.
+		if f.Rule == RuleShadowedResult && len(f.Edits) > 0 {
+			t.Errorf("%s: unexpected fix %+v", f.Message, f.Edits)
+		}
+	}
+	expected := []string{
+		"warning naked return in func `Long` with 4 lines of code",
+		"info naked return in func `Short` with 1 lines of code",
+		"error naked return in func `Shadowed` with 8 lines of code while result `err` is shadowed",
+		"error naked return in func `Shadowed` with 8 lines of code while result `n` is shadowed",
+		"warning naked return in func `Shadowed` with 8 lines of code",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n-----\ngot: \n%s\nexpected: \n%s\n-----\n", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestSeverityShortNotReported(t *testing.T) {
+	findings := checkSource(t, NakedReturnRunner{MaxLength: 10, GroupByFunction: true}, severitySrc)
+	if len(findings) != 1 || findings[0].Severity != SeverityError || len(findings[0].Related) != 2 {
+		t.Fatalf("expected the shadowed returns of Shadowed only, got %+v", findings)
+	}
+	if f := findings[0]; len(f.Edits) > 0 || len(f.Related[0].Edits) > 0 || len(f.Related[1].Edits) > 0 {
+		t.Errorf("expected no fix for shadowed returns, got %+v", f)
+	}
+}
+
+func TestSeverityGroupedFix(t *testing.T) {
+	findings := checkSource(t, NakedReturnRunner{MaxLength: 3, GroupByFunction: true}, severitySrc)
+	if len(findings) != 2 || findings[1].Func != "Shadowed" || len(findings[1].Related) != 3 {
+		t.Fatalf("expected Long and Shadowed, got %+v", findings)
+	}
+	// Only the return that is not shadowed is made explicit.
+	f := findings[1]
+	if len(f.Edits) != 1 || f.Edits[0].NewText != "return n, err" || len(f.Related[0].Edits) > 0 || len(f.Related[1].Edits) > 0 || len(f.Related[2].Edits) != 1 {
+		t.Errorf("unexpected fix of Shadowed %+v", f)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		text, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed Severity
+		if err := json.Unmarshal(text, &parsed); err != nil || parsed != s {
+			t.Errorf("%s: got %v (%v) from %s", s, parsed, err, text)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
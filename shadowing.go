@@ -0,0 +1,150 @@
+package nakedret
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// outerObject is a predeclared, imported or package-level identifier that a
+// named result can shadow.
+type outerObject struct {
+	// kind describes the object, e.g. "builtin" or "package-level func".
+	kind string
+	// pos is its declaration, token.NoPos for predeclared identifiers.
+	pos token.Pos
+}
+
+// packageDecls returns the package-level declarations of files by name.
+func packageDecls(files []*ast.File) map[string]outerObject {
+	decls := make(map[string]outerObject)
+	add := func(kind string, name *ast.Ident) {
+		if name.Name != "_" {
+			decls[name.Name] = outerObject{"package-level " + kind, name.Pos()}
+		}
+	}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name != "init" {
+					add("func", decl.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							add(decl.Tok.String(), name)
+						}
+					case *ast.TypeSpec:
+						add("type", spec.Name)
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// majorVersion matches the major version suffix of an import path, e.g. /v2
+// or, for gopkg.in, .v3.
+var majorVersion = regexp.MustCompile(`[/.]v[0-9]+$`)
+
+// importName guesses the name of the package imported by spec, without type
+// information, from the last element of its path.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	p, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	if trimmed := majorVersion.ReplaceAllString(p, ""); trimmed != "" && trimmed != p {
+		p = trimmed
+	}
+	return path.Base(p)
+}
+
+// lookupOuter returns the object named name that is visible in file outside
+// of any function, if any. The objects are looked up in the type information
+// of the pass if available, and otherwise syntactically.
+func (v *returnsVisitor) lookupOuter(file *ast.File, name string) (outerObject, bool) {
+	if info, pkg := v.pass.TypesInfo, v.pass.Pkg; info != nil && pkg != nil {
+		if scope := info.Scopes[file]; scope != nil {
+			if pkgName, ok := scope.Lookup(name).(*types.PkgName); ok {
+				return outerObject{"imported package", pkgName.Pos()}, true
+			}
+		}
+		switch obj := pkg.Scope().Lookup(name).(type) {
+		case *types.Func:
+			return outerObject{"package-level func", obj.Pos()}, true
+		case *types.Var:
+			return outerObject{"package-level var", obj.Pos()}, true
+		case *types.Const:
+			return outerObject{"package-level const", obj.Pos()}, true
+		case *types.TypeName:
+			return outerObject{"package-level type", obj.Pos()}, true
+		}
+	} else {
+		for _, spec := range file.Imports {
+			if importName(spec) == name {
+				return outerObject{"imported package", spec.Pos()}, true
+			}
+		}
+		if obj, ok := v.pkgDecls[name]; ok {
+			return obj, true
+		}
+	}
+	if types.Universe.Lookup(name) != nil {
+		return outerObject{"builtin", token.NoPos}, true
+	}
+	return outerObject{}, false
+}
+
+// checkShadowingResults reports the named results of the function on top of
+// the stack that shadow a predeclared, imported or package-level identifier,
+// with the declaration of the latter, if any, as related information.
+func (v *returnsVisitor) checkShadowingResults(file *ast.File) {
+	fun := v.functions[len(v.functions)-1]
+	funName := nestedFuncName(v.functions)
+	for _, field := range fun.funcType.Results.List {
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				continue
+			}
+			obj, ok := v.lookupOuter(file, name.Name)
+			if !ok {
+				continue
+			}
+			rule := v.rule(name, map[string]bool{RuleShadowingResult: true})
+			if rule == "" {
+				continue
+			}
+			what := fmt.Sprintf("%s `%s`", obj.kind, name.Name)
+			var related []analysis.RelatedInformation
+			if obj.pos.IsValid() {
+				related = append(related, analysis.RelatedInformation{
+					Pos:     obj.pos,
+					End:     obj.pos,
+					Message: what,
+				})
+			}
+			v.report(newNakedReturn(analysis.Diagnostic{
+				Pos:      name.Pos(),
+				End:      name.End(),
+				Category: rule,
+				URL:      RuleURL(rule),
+				Message:  fmt.Sprintf("named result `%s` of func `%s` shadows the %s", name.Name, funName, what),
+				Related:  related,
+			}, funName, fun, 0))
+		}
+	}
+}
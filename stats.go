@@ -0,0 +1,96 @@
+package nakedret
+
+import (
+	"cmp"
+	"fmt"
+	"go/token"
+	"slices"
+)
+
+// PackageStats summarizes the functions of a package.
+type PackageStats struct {
+	Dir, Name string
+
+	// NamedResults is the number of functions with named results,
+	// NakedReturns the number of those using naked returns and OverLimit
+	// the number of those longer than their maximum length, which are the
+	// ones reported.
+	NamedResults int
+	NakedReturns int
+	OverLimit    int
+
+	// Funcs holds every function of the package, including function literals.
+	Funcs []FuncStats
+}
+
+// FuncStats describes a function of a package.
+type FuncStats struct {
+	// Name is the name of the function, prefixed by the names of the
+	// functions enclosing it.
+	Name string
+	Pos  token.Position
+	// Length is the number of lines of the function and MaxLength the
+	// maximum length allowed for it.
+	Length    int
+	MaxLength uint
+
+	NamedResults bool
+	// NakedReturns is the number of naked returns in the function, whether
+	// or not they are reported.
+	NakedReturns int
+}
+
+// Stats parses the files, directories or packages named by patterns like
+// Check and returns statistics about the functions of each package.
+func Stats(patterns []string, opts Options) ([]PackageStats, error) {
+	fset := token.NewFileSet()
+
+	files, _, err := opts.parseInput(patterns, fset)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse input: %v", err)
+	}
+
+	pkgs := groupPackages(fset, files)
+	stats := make([]PackageStats, len(pkgs))
+	err = forEach(len(pkgs), opts.Jobs, func(i int) error {
+		pass, err := opts.newPass(fset, pkgs[i].files)
+		if err != nil {
+			return err
+		}
+
+		s := PackageStats{Dir: pkgs[i].dir, Name: pkgs[i].name}
+		opts.visit(pass, func(nakedReturn) {}, func(name string, fun funcInfo) {
+			f := FuncStats{
+				Name:         name,
+				Pos:          fset.Position(fun.funcPos),
+				Length:       fun.funcLength,
+				MaxLength:    fun.maxLength,
+				NamedResults: fun.namedResults,
+				NakedReturns: fun.nakedCount,
+			}
+			if f.NamedResults {
+				s.NamedResults++
+			}
+			if f.NakedReturns > 0 {
+				s.NakedReturns++
+				if uint(f.Length) > f.MaxLength {
+					s.OverLimit++
+				}
+			}
+			s.Funcs = append(s.Funcs, f)
+		})
+		// Functions are popped after the ones they enclose.
+		slices.SortFunc(s.Funcs, func(a, b FuncStats) int {
+			return cmp.Or(
+				cmp.Compare(a.Pos.Filename, b.Pos.Filename),
+				cmp.Compare(a.Pos.Offset, b.Pos.Offset),
+			)
+		})
+		stats[i] = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
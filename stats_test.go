@@ -0,0 +1,54 @@
+package nakedret
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	stats, err := Stats([]string{"testdata/src/x/example.go", "testdata/src/x/ret-in-block.go"}, Options{NakedReturnRunner: NakedReturnRunner{MaxLength: 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected a single package, got %+v", stats)
+	}
+
+	s := stats[0]
+	if s.Name != "x" || s.Dir != "testdata/src/x" {
+		t.Errorf("unexpected package %s in %s", s.Name, s.Dir)
+	}
+	if s.NamedResults != 5 || s.NakedReturns != 5 || s.OverLimit != 3 {
+		t.Errorf("unexpected totals: %d with named results, %d with naked returns, %d over the limit", s.NamedResults, s.NakedReturns, s.OverLimit)
+	}
+
+	expected := []FuncStats{
+		{Name: "justone", Length: 3, NakedReturns: 1},
+		{Name: "both", Length: 4, NakedReturns: 1},
+		{Name: "three", Length: 5, NakedReturns: 1},
+		{Name: "longFunc", Length: 34, NakedReturns: 1},
+		{Name: "Dummy", Length: 8, NakedReturns: 1},
+	}
+	if len(s.Funcs) != len(expected) {
+		t.Fatalf("got %d functions, expected %d: %+v", len(s.Funcs), len(expected), s.Funcs)
+	}
+	for i, f := range s.Funcs {
+		if f.Name != expected[i].Name || f.Length != expected[i].Length || f.NakedReturns != expected[i].NakedReturns || !f.NamedResults || f.MaxLength != 4 {
+			t.Errorf("unexpected function %+v, expected %+v", f, expected[i])
+		}
+	}
+}
+
+func TestStatsNested(t *testing.T) {
+	stats, err := Stats([]string{"testdata/src/x/nested.go"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range stats[0].Funcs[:3] {
+		names = append(names, f.Name)
+	}
+	if got := names[0] + " " + names[1] + " " + names[2]; got != "Okay Okay.defer#1 Bad" {
+		t.Errorf("functions not sorted by position: %s", got)
+	}
+	if f := stats[0].Funcs[0]; f.NamedResults != true || f.NakedReturns != 0 {
+		t.Errorf("unexpected stats for Okay: %+v", f)
+	}
+}
@@ -0,0 +1,38 @@
+package deferred
+
+import "fmt"
+
+func Recovered() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	_ = 0
+	return // want "naked return in func `Recovered` with 8 lines of code; results modified by defer at line 6"
+}
+
+// The deferred literal assigns its own parameter.
+func Shadowed() (err error) {
+	defer func(err error) {
+		err = nil
+	}(nil)
+	_ = 0
+	return // want "naked return in func `Shadowed` with 6 lines of code \\(NR001\\)"
+}
+
+// The defer belongs to the literal, not to Nested.
+func Nested() (err error) {
+	f := func() (n int) {
+		defer func() { n++ }()
+		return // want "naked return in func `Nested.f` with 3 lines of code; results modified by defer at line 27"
+	}
+	_ = f
+	return // want "naked return in func `Nested` with 7 lines of code \\(NR001\\)"
+}
+
+func Plain() (err error) {
+	_ = 0
+	_ = 0
+	return // want "naked return in func `Plain` with 4 lines of code \\(NR001\\)"
+}
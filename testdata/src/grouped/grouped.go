@@ -0,0 +1,24 @@
+package grouped
+
+func ManyReturns() (x, y int, err error) { // want "3 naked returns in func `ManyReturns` with 8 lines of code"
+	switch {
+	case true:
+		return
+	case false:
+		return
+	}
+	return
+}
+
+func Nested() (err error) { // want "naked return in func `Nested` with 7 lines of code"
+	f := func() (x int) { // want "naked return in func `Nested.f` with 3 lines of code"
+		x = 1
+		return
+	}
+	_ = f
+	return
+}
+
+func Explicit() (err error) {
+	return err
+}
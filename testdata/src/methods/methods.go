@@ -0,0 +1,33 @@
+package methods
+
+type Server struct{}
+
+type Client struct{}
+
+func (s *Server) Close() (err error) {
+	return // want "naked return in func `\\(\\*Server\\).Close` with 2 lines of code \\(NR001\\)"
+}
+
+func (c Client) Close() (err error) {
+	return // want "naked return in func `Client.Close` with 2 lines of code"
+}
+
+func (*Client) Reset() (ok bool) {
+	return // want "naked return in func `\\(\\*Client\\).Reset` with 2 lines of code"
+}
+
+type List[T any] struct{}
+
+func (l *List[T]) Pop() (v T, ok bool) {
+	return // want "naked return in func `\\(\\*List\\[T\\]\\).Pop` with 2 lines of code"
+}
+
+type Map[K comparable, V any] struct{}
+
+func (m Map[K, V]) Get(k K) (v V) {
+	f := func() (ok bool) {
+		return // want "naked return in func `Map\\[K, V\\].Get.f` with 2 lines of code"
+	}
+	_ = f
+	return // want "naked return in func `Map\\[K, V\\].Get` with 6 lines of code"
+}
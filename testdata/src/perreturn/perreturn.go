@@ -0,0 +1,11 @@
+package perreturn
+
+func Guarded(s string) (n int, err error) {
+	if s == "" {
+		return
+	}
+	_ = 0
+	_ = 0
+	n = len(s)
+	return // want "naked return at line 7 of func `Guarded` \\(NR001\\)"
+}
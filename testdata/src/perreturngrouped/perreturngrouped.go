@@ -0,0 +1,22 @@
+package perreturngrouped
+
+func Guarded(s string) (n int, err error) { // want "naked return at line 7 of func `Guarded`"
+	if s == "" {
+		return
+	}
+	_ = 0
+	_ = 0
+	n = len(s)
+	return
+}
+
+func Late(s string) (n int, err error) { // want "2 naked returns at lines 6 and 8 of func `Late`"
+	_ = 0
+	_ = 0
+	_ = 0
+	_ = 0
+	if s == "" {
+		return
+	}
+	return
+}
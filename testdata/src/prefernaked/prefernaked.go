@@ -0,0 +1,52 @@
+package prefernaked
+
+import "strconv"
+
+func Parse(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return n, err // want "explicit return of the named results in func `Parse` with 3 lines of code"
+}
+
+func Swapped(s string) (n int, err error) {
+	return len(s), err
+}
+
+func Reordered() (a, b int) {
+	return b, a
+}
+
+func Shadowed(s string) (n int, err error) {
+	if n, err := strconv.Atoi(s); err != nil {
+		return n, err
+	}
+	return n, err
+}
+
+func Long(s string) (n int, err error) {
+	n = len(s)
+	if n == 0 {
+		err = strconv.ErrSyntax
+	}
+	return n, err
+}
+
+// A naked return would exceed the limit of the function.
+//
+//nakedret:max-length 1
+func Limited(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return n, err
+}
+
+func Unnamed() (int, error) {
+	return 0, nil
+}
+
+func Blank() (_ int, err error) {
+	return 0, err
+}
+
+func Literal() {
+	f := func() (ok bool) { return ok } // want "explicit return of the named results in func `Literal.f` with 1 lines of code"
+	_ = f
+}
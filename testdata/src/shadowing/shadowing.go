@@ -0,0 +1,31 @@
+package shadowing
+
+import (
+	"errors"
+	str "strings"
+)
+
+type config struct{}
+
+var defaults = config{}
+
+func Lengths(xs []string) (len int, errors []error) { // want "named result `len` of func `Lengths` shadows the builtin `len`" "named result `errors` of func `Lengths` shadows the imported package `errors`"
+	return
+}
+
+func Load() (config config, err error) { // want "named result `config` of func `Load` shadows the package-level type `config`"
+	err = errors.New(str.ToUpper("todo"))
+	return
+}
+
+func Reset() (defaults int) { // want "named result `defaults` of func `Reset` shadows the package-level var `defaults`"
+	f := func() (str string) { // want "named result `str` of func `Reset.f` shadows the imported package `str`"
+		return
+	}
+	_ = f
+	return
+}
+
+func Fine() (n int, err error) {
+	return
+}
@@ -0,0 +1,52 @@
+package unused
+
+import "errors"
+
+type conn struct{ err error }
+
+func Documented() (count int, err error) { // want "named results `count`, `err` of func `Documented` are never used"
+	return 0, nil
+}
+
+func Single() (n int) { // want "named result `n` of func `Single` is never used"
+	return 1
+}
+
+func Grouped() (a, b int, c string) { // want "named results `a`, `b`, `c` of func `Grouped` are never used"
+	return 1, 2, ""
+}
+
+func Naked() (ok bool) { // want "named result `ok` of func `Naked` is only used by naked returns"
+	return
+}
+
+func Partly(c conn) (n int, err error) { // want "named result `n` of func `Partly` is never used"
+	err = c.err
+	return 0, err
+}
+
+func Used() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("panic")
+		}
+	}()
+	return nil
+}
+
+func Blank() (_ int, err error) {
+	return 0, err
+}
+
+func Unnamed() (int, error) {
+	return 0, nil
+}
+
+func Literal() {
+	f := func() (x int) { return 0 } // want "named result `x` of func `Literal.f` is never used"
+	_ = f
+}
+
+func Commented() (count int /* items */, err error) { // want "named results `count`, `err` of func `Commented` are never used"
+	return 0, nil
+}
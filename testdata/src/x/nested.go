@@ -18,33 +18,33 @@ func Bad() (err error) {
 
 func BadNested() {
 	_ = func() (i int) {
-		return // want "naked return in func `BadNested.<func..:20>` with 2 lines of code"
+		return // want "naked return in func `BadNested.func#1` with 2 lines of code"
 	}
 	return
 }
 
 func MoreBad() {
 	var _ = func() (err error) {
-		return // want "naked return in func `MoreBad.<func..:27>` with 2 lines of code"
+		return // want "naked return in func `MoreBad.func#1` with 2 lines of code"
 	}
 
 	func() (err error) {
-		return // want "naked return in func `MoreBad.<func..:31>` with 2 lines of code"
+		return // want "naked return in func `MoreBad.func#2` with 2 lines of code"
 	}()
 
 	defer func() (err error) {
-		return // want "naked return in func `MoreBad.<func..:35>` with 2 lines of code"
+		return // want "naked return in func `MoreBad.defer#1` with 2 lines of code"
 	}()
 
 	go func() (err error) {
-		return // want "naked return in func `MoreBad.<func..:39>` with 2 lines of code"
+		return // want "naked return in func `MoreBad.go#1` with 2 lines of code"
 	}()
 }
 
 func LiteralFuncCallReturn() int {
 	// function literal nested within a return statement
 	return func() (x int) {
-		return // want "naked return in func `LiteralFuncCallReturn.<func..:46>` with 2 lines of code"
+		return // want "naked return in func `LiteralFuncCallReturn.func#1` with 2 lines of code"
 	}()
 }
 
@@ -52,7 +52,7 @@ func LiteralFuncCallReturn2() int {
 	// function literal nested within a return statement
 	return func() (x int) {
 		return func() (x int) {
-			return // want "naked return in func `LiteralFuncCallReturn2.<func..:53>.<func..:54>` with 2 lines of code"
+			return // want "naked return in func `LiteralFuncCallReturn2.func#1.func#1` with 2 lines of code"
 		}()
 	}()
 }
@@ -75,16 +75,16 @@ func DeeplyNested(b int) (f func() (x, y int)) {
 				case true:
 					x = func() (a int) {
 						a = b
-						return // want "naked return in func `DeeplyNested.<func..:71>.<func..:72>.<func..:73>.<func..:76>` with 3 lines of code"
+						return // want "naked return in func `DeeplyNested.f.defer#1.func#1.func#1` with 3 lines of code"
 					}()
 					if x > y {
-						return // want "naked return in func `DeeplyNested.<func..:71>.<func..:72>.<func..:73>` with 12 lines of code"
+						return // want "naked return in func `DeeplyNested.f.defer#1.func#1` with 12 lines of code"
 					}
 				}
-				return // want "naked return in func `DeeplyNested.<func..:71>.<func..:72>.<func..:73>` with 12 lines of code"
+				return // want "naked return in func `DeeplyNested.f.defer#1.func#1` with 12 lines of code"
 			}()
 		}()
-		return // want "naked return in func `DeeplyNested.<func..:71>` with 17 lines of code"
+		return // want "naked return in func `DeeplyNested.f` with 17 lines of code"
 	}
 	return // want "naked return in func `DeeplyNested` with 20 lines of code"
 }
@@ -92,16 +92,16 @@ func DeeplyNested(b int) (f func() (x, y int)) {
 var ToplevelFuncLit = func(x int) (err error) {
 	if x > 0 {
 		return func() (err error) {
-			return // want "naked return in func `<func..:92>.<func..:94>` with 2 lines of code"
+			return // want "naked return in func `ToplevelFuncLit.func#1` with 2 lines of code"
 		}()
 	}
-	return // want "naked return in func `<func..:92>` with 7 lines of code"
+	return // want "naked return in func `ToplevelFuncLit` with 7 lines of code"
 }
 
 func SingleLine() (err error) { return } // want "naked return in func `SingleLine` with 1 lines of code"
 
-var SingleLit = func() (err error) { return } // want "naked return in func `<func..:103>` with 1 lines of code"
+var SingleLit = func() (err error) { return } // want "naked return in func `SingleLit` with 1 lines of code"
 
 func SingleLineNested() (err error) {
-	return func() (err error) { return }() // want "naked return in func `SingleLineNested.<func..:106>` with 1 lines of code"
+	return func() (err error) { return }() // want "naked return in func `SingleLineNested.func#1` with 1 lines of code"
 }
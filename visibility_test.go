@@ -0,0 +1,71 @@
+package nakedret
+
+import (
+	"strings"
+	"testing"
+)
+
+const visibilitySrc = `package %s
+
+type Exported struct{}
+
+type unexported struct{}
+
+func Func() (err error) {
+	_ = 0
+	return
+}
+
+func (Exported) Method() (err error) {
+	_ = 0
+	return
+}
+
+func (*unexported) Method() (err error) {
+	_ = 0
+	return
+}
+
+func helper() (err error) {
+	_ = 0
+	f := func() (err error) {
+		return
+	}
+	_ = f
+	return
+}
+`
+
+func TestVisibilityMaxLengths(t *testing.T) {
+	zero, three, ten := uint(0), uint(3), uint(10)
+	for _, tt := range []struct {
+		name     string
+		runner   NakedReturnRunner
+		pkg      string
+		expected []string
+	}{
+		{"max length", NakedReturnRunner{MaxLength: 1}, "x",
+			[]string{"Func", "Exported.Method", "(*unexported).Method", "helper.f", "helper"}},
+		{"strict exported API", NakedReturnRunner{MaxLength: 10, ExportedMaxLength: &zero, ExportedMethodMaxLength: &zero}, "x",
+			[]string{"Func", "Exported.Method"}},
+		{"loose unexported", NakedReturnRunner{UnexportedMaxLength: &ten}, "x",
+			[]string{"Func", "Exported.Method", "helper.f"}},
+		{"literals", NakedReturnRunner{MaxLength: 2, LiteralMaxLength: &zero, UnexportedMaxLength: &three}, "x",
+			[]string{"Func", "Exported.Method", "helper.f", "helper"}},
+		{"main", NakedReturnRunner{}, "main",
+			[]string{"Func", "Exported.Method", "(*unexported).Method", "helper.f", "helper"}},
+		{"skip main", NakedReturnRunner{SkipMain: true}, "main", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src := strings.Replace(visibilitySrc, "%s", tt.pkg, 1)
+			findings := checkSource(t, tt.runner, src)
+			var names []string
+			for _, f := range findings {
+				names = append(names, f.Func)
+			}
+			if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("got %q, expected %q", names, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package nakedret
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce is how long Watch waits for a burst of writes to settle before
+// checking the changed packages again.
+const debounce = 100 * time.Millisecond
+
+// Watch checks patterns like Check and then watches the directories the
+// files were loaded from. Whenever Go files change, only the packages in the
+// affected directories are checked again and onChange is called with the
+// findings that appeared and the ones that were resolved. The first call
+// reports every finding as added. Watch returns when ctx is done.
+func Watch(ctx context.Context, patterns []string, opts Options, onChange func(added, resolved []Finding)) error {
+	fset := token.NewFileSet()
+	files, fileMode, err := opts.parseInput(patterns, fset)
+	if err != nil {
+		return fmt.Errorf("could not parse input: %v", err)
+	}
+
+	w := &watcher{
+		opts:     opts,
+		fileMode: fileMode,
+		dirs:     make(map[string]*watchedDir),
+	}
+	pkgs := groupPackages(fset, files)
+	pkgFindings, err := opts.checkPackages(fset, pkgs)
+	if err != nil {
+		return err
+	}
+	var all []Finding
+	for i, p := range pkgs {
+		dir := w.dirs[p.dir]
+		if dir == nil {
+			dir = &watchedDir{}
+			w.dirs[p.dir] = dir
+		}
+		for _, f := range p.files {
+			dir.files = append(dir.files, fset.File(f.Pos()).Name())
+		}
+		dir.findings = append(dir.findings, pkgFindings[i]...)
+		all = append(all, pkgFindings[i]...)
+	}
+	sortFindings(all)
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+	for dir := range w.dirs {
+		if err := fsw.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	onChange(all, nil)
+
+	changed := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if !strings.HasSuffix(ev.Name, ".go") || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if w.update(ev) {
+				changed[filepath.Dir(ev.Name)] = true
+				timer.Reset(debounce)
+			}
+		case <-timer.C:
+			var added, resolved []Finding
+			for dir := range changed {
+				a, r := w.recheck(dir)
+				added = append(added, a...)
+				resolved = append(resolved, r...)
+			}
+			clear(changed)
+			if len(added) > 0 || len(resolved) > 0 {
+				onChange(added, resolved)
+			}
+		}
+	}
+}
+
+type watcher struct {
+	opts     Options
+	fileMode bool
+
+	// dirs holds the watched directories, by name.
+	dirs map[string]*watchedDir
+}
+
+type watchedDir struct {
+	// files are the names of the files loaded from the directory.
+	files    []string
+	findings []Finding
+}
+
+// update reports whether the directory a file system event happened in
+// needs to be checked again.
+func (w *watcher) update(ev fsnotify.Event) bool {
+	dir := w.dirs[filepath.Dir(ev.Name)]
+	if dir == nil {
+		return false
+	}
+	if !w.fileMode {
+		// The directory is parsed again as a whole.
+		return true
+	}
+	// Files created next to the ones named on the command line are not part of the input.
+	for _, name := range dir.files {
+		if filepath.Clean(name) == filepath.Clean(ev.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// recheck parses the files of dir again, replaces its findings and returns
+// how they changed.
+func (w *watcher) recheck(dirname string) (added, resolved []Finding) {
+	dir := w.dirs[dirname]
+	args := []string{dirname}
+	if w.fileMode {
+		// Editors may replace a file by renaming it away and writing a new
+		// one, so only skip the files that are currently missing.
+		args = nil
+		for _, name := range dir.files {
+			if _, ok := w.opts.Overlay[name]; ok || exists(name) {
+				args = append(args, name)
+			}
+		}
+	}
+
+	var findings []Finding
+	if len(args) > 0 {
+		fset := token.NewFileSet()
+		files, _, err := w.opts.parseInput(args, fset)
+		if err != nil {
+			// Keep the previous findings until the files parse again.
+			log.Printf("could not parse input: %v", err)
+			return nil, nil
+		}
+		pkgFindings, err := w.opts.checkPackages(fset, groupPackages(fset, files))
+		if err != nil {
+			log.Print(err)
+			return nil, nil
+		}
+		for _, f := range pkgFindings {
+			findings = append(findings, f...)
+		}
+	}
+
+	added, resolved = diffFindings(dir.findings, findings)
+	dir.findings = findings
+	return added, resolved
+}
+
+// diffFindings returns the findings of next that are not in prev and those of
+// prev that are not in next. Findings are compared regardless of their line,
+// so that edits above a naked return do not report it again.
+func diffFindings(prev, next []Finding) (added, resolved []Finding) {
+	type key struct{ filename, message, fix string }
+	keyOf := func(f Finding) key {
+		var fix []string
+		for _, edit := range f.Edits {
+			fix = append(fix, edit.NewText)
+		}
+		return key{f.Pos.Filename, f.Message, strings.Join(fix, "\x00")}
+	}
+
+	prevCount := make(map[key]int)
+	for _, f := range prev {
+		prevCount[keyOf(f)]++
+	}
+	nextCount := make(map[key]int)
+	for _, f := range next {
+		nextCount[keyOf(f)]++
+	}
+
+	for _, f := range next {
+		if k := keyOf(f); prevCount[k] > 0 {
+			prevCount[k]--
+		} else {
+			added = append(added, f)
+		}
+	}
+	for _, f := range prev {
+		if k := keyOf(f); nextCount[k] > 0 {
+			nextCount[k]--
+		} else {
+			resolved = append(resolved, f)
+		}
+	}
+	return added, resolved
+}
@@ -0,0 +1,88 @@
+package nakedret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiffFindings(t *testing.T) {
+	finding := func(filename string, line int, message string) Finding {
+		f := Finding{Message: message}
+		f.Pos.Filename = filename
+		f.Pos.Line = line
+		return f
+	}
+	prev := []Finding{
+		finding("a.go", 3, "naked return in func `A`"),
+		finding("a.go", 5, "naked return in func `A`"),
+		finding("b.go", 3, "naked return in func `B`"),
+	}
+	next := []Finding{
+		// Moved down by an edit above it: not a new finding.
+		finding("a.go", 4, "naked return in func `A`"),
+		finding("c.go", 3, "naked return in func `C`"),
+	}
+
+	added, resolved := diffFindings(prev, next)
+	if len(added) != 1 || added[0].Pos.Filename != "c.go" {
+		t.Errorf("unexpected added findings %+v", added)
+	}
+	if len(resolved) != 2 || resolved[0].Pos.Line != 5 || resolved[1].Pos.Filename != "b.go" {
+		t.Errorf("unexpected resolved findings %+v", resolved)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "x.go")
+	write := func(src string) {
+		t.Helper()
+		if err := os.WriteFile(filename, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package x\n\nfunc A() (err error) {\n\treturn\n}\n")
+
+	type change struct{ added, resolved []Finding }
+	changes := make(chan change, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, []string{dir}, Options{}, func(added, resolved []Finding) {
+			changes <- change{added, resolved}
+		})
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	next := func() change {
+		t.Helper()
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for findings")
+		}
+		panic("unreachable")
+	}
+
+	if c := next(); len(c.added) != 1 || len(c.resolved) != 0 {
+		t.Fatalf("unexpected initial findings %+v", c)
+	}
+
+	write("package x\n\nfunc A() (err error) {\n\treturn err\n}\n\nfunc B() (err error) {\n\treturn\n}\n")
+	c := next()
+	if len(c.added) != 1 || c.added[0].Message != "naked return in func `B` with 2 lines of code" {
+		t.Errorf("unexpected added findings %+v", c.added)
+	}
+	if len(c.resolved) != 1 || c.resolved[0].Message != "naked return in func `A` with 2 lines of code" {
+		t.Errorf("unexpected resolved findings %+v", c.resolved)
+	}
+}